@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_products_active_name;
+DROP INDEX IF EXISTS idx_products_active_price;
+DROP INDEX IF EXISTS idx_products_description_trgm;
+DROP INDEX IF EXISTS idx_products_name_trgm;
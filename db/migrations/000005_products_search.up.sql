@@ -0,0 +1,15 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm
+ON products USING gin (name gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_products_description_trgm
+ON products USING gin (description gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_products_active_price
+ON products(price_cents, id)
+WHERE is_active;
+
+CREATE INDEX IF NOT EXISTS idx_products_active_name
+ON products(name, id)
+WHERE is_active;
@@ -0,0 +1,9 @@
+ALTER TABLE order_items
+ALTER COLUMN unit_price_cents TYPE INT,
+ALTER COLUMN line_total_cents TYPE INT;
+
+ALTER TABLE orders
+ALTER COLUMN total_cents TYPE INT;
+
+ALTER TABLE products
+ALTER COLUMN price_cents TYPE FLOAT;
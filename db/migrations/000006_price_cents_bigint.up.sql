@@ -0,0 +1,11 @@
+-- Existing FLOAT prices are rounded half away from zero (numeric round), so
+-- the conversion does not depend on the platform's float rounding mode.
+ALTER TABLE products
+ALTER COLUMN price_cents TYPE BIGINT USING round(price_cents::numeric)::bigint;
+
+ALTER TABLE orders
+ALTER COLUMN total_cents TYPE BIGINT;
+
+ALTER TABLE order_items
+ALTER COLUMN unit_price_cents TYPE BIGINT,
+ALTER COLUMN line_total_cents TYPE BIGINT;
@@ -0,0 +1,7 @@
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD'
+CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE orders
+ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD'
+CHECK (currency ~ '^[A-Z]{3}$');
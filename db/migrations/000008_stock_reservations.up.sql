@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id BIGSERIAL PRIMARY KEY,
+    cart_id BIGINT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    qty INT NOT NULL CHECK (qty > 0),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_stock_reservations_cart_product
+ON stock_reservations(cart_id, product_id);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product_expires
+ON stock_reservations(product_id, expires_at);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expires
+ON stock_reservations(expires_at);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS email_change_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    new_email CITEXT NOT NULL,
+    token_hash TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_email_change_tokens_hash
+ON email_change_tokens(token_hash);
+
+CREATE INDEX IF NOT EXISTS idx_email_change_tokens_user
+ON email_change_tokens(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_email_change_tokens_expires
+ON email_change_tokens(expires_at);
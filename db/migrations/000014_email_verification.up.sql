@@ -0,0 +1,23 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- accounts that predate verification keep working
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_email_verification_tokens_hash
+ON email_verification_tokens(token_hash);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user_created
+ON email_verification_tokens(user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_expires
+ON email_verification_tokens(expires_at);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_user_identities_provider_subject
+ON user_identities(provider, subject);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user
+ON user_identities(user_id);
+
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    id BIGSERIAL PRIMARY KEY,
+    state_hash TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_oidc_login_states_hash
+ON oidc_login_states(state_hash);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_login_states_expires
+ON oidc_login_states(expires_at);
@@ -0,0 +1,57 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('products:write', 'Create, update and delete products'),
+    ('orders:read', 'View any order''s status history'),
+    ('orders:write', 'Change the status of any order'),
+    ('users:write', 'Unlock user accounts'),
+    ('roles:read', 'View roles and who holds them'),
+    ('roles:write', 'Assign and remove user roles')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access'),
+    ('catalog_manager', 'Manages the product catalog'),
+    ('order_manager', 'Handles orders'),
+    ('support', 'Helps customers with their accounts and orders')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('catalog_manager', 'products:write'),
+    ('order_manager', 'orders:read'),
+    ('order_manager', 'orders:write'),
+    ('support', 'orders:read'),
+    ('support', 'users:write')
+ON CONFLICT DO NOTHING;
+
+-- customers hold no role; only admins carry over
+INSERT INTO user_roles (user_id, role)
+SELECT id, 'admin' FROM users WHERE role = 'admin'
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,7 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_requested
+ON users(deletion_requested_at)
+WHERE deletion_requested_at IS NOT NULL AND deleted_at IS NULL;
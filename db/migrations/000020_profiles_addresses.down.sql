@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS addresses;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS phone,
+DROP COLUMN IF EXISTS last_name,
+DROP COLUMN IF EXISTS first_name;
@@ -0,0 +1,33 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS first_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS last_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS phone TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS addresses (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    full_name TEXT NOT NULL,
+    company TEXT NOT NULL DEFAULT '',
+    line1 TEXT NOT NULL,
+    line2 TEXT NOT NULL DEFAULT '',
+    city TEXT NOT NULL,
+    region TEXT NOT NULL DEFAULT '',
+    postal_code TEXT NOT NULL DEFAULT '',
+    country TEXT NOT NULL CHECK (country ~ '^[A-Z]{2}$'),
+    phone TEXT NOT NULL DEFAULT '',
+    is_default_shipping BOOLEAN NOT NULL DEFAULT false,
+    is_default_billing BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_addresses_user
+ON addresses(user_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_addresses_default_shipping
+ON addresses(user_id)
+WHERE is_default_shipping;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_addresses_default_billing
+ON addresses(user_id)
+WHERE is_default_billing;
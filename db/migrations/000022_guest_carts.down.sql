@@ -0,0 +1,10 @@
+DELETE FROM carts WHERE user_id IS NULL;
+
+DROP INDEX IF EXISTS idx_carts_guest_updated;
+DROP INDEX IF EXISTS ux_carts_guest_token;
+
+ALTER TABLE carts DROP CONSTRAINT IF EXISTS carts_owner_check;
+
+ALTER TABLE carts
+DROP COLUMN IF EXISTS guest_token_hash,
+ALTER COLUMN user_id SET NOT NULL;
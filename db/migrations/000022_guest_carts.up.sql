@@ -0,0 +1,15 @@
+ALTER TABLE carts
+ALTER COLUMN user_id DROP NOT NULL,
+ADD COLUMN IF NOT EXISTS guest_token_hash TEXT;
+
+ALTER TABLE carts
+ADD CONSTRAINT carts_owner_check
+CHECK ((user_id IS NULL) <> (guest_token_hash IS NULL));
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_carts_guest_token
+ON carts(guest_token_hash)
+WHERE guest_token_hash IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_carts_guest_updated
+ON carts(updated_at)
+WHERE user_id IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE idempotency_keys
+ADD COLUMN IF NOT EXISTS response_headers TEXT NOT NULL DEFAULT '';
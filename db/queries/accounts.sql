@@ -0,0 +1,116 @@
+-- name: GetUserForExport :one
+SELECT id, email, first_name, last_name, phone, email_verified_at, created_at, updated_at, deletion_requested_at
+FROM users
+WHERE id = $1;
+
+-- name: ListUserIdentities :many
+SELECT provider, email, created_at
+FROM user_identities
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: ListCartsForUser :many
+SELECT id, status, created_at, updated_at
+FROM carts
+WHERE user_id = $1::bigint
+ORDER BY id;
+
+-- name: ListCartItemsForUser :many
+SELECT ci.cart_id, ci.product_id, p.name, ci.qty, ci.created_at, ci.updated_at
+FROM cart_items ci
+JOIN carts c ON c.id = ci.cart_id
+JOIN products p ON p.id = ci.product_id
+WHERE c.user_id = $1::bigint
+ORDER BY ci.cart_id, ci.id;
+
+-- name: ListAllOrdersForUser :many
+SELECT id, user_id, status, total_cents, created_at, currency
+FROM orders
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: ListOrderItemsForUser :many
+SELECT oi.order_id, oi.product_id, p.name, oi.unit_price_cents, oi.qty, oi.line_total_cents
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN products p ON p.id = oi.product_id
+WHERE o.user_id = $1
+ORDER BY oi.order_id, oi.id;
+
+-- name: ListOrderStatusHistoryForUser :many
+SELECT h.order_id, h.from_status, h.to_status, h.reason, h.created_at
+FROM order_status_history h
+JOIN orders o ON o.id = h.order_id
+WHERE o.user_id = $1
+ORDER BY h.order_id, h.id;
+
+-- name: ListOrderAddressesForUser :many
+SELECT oa.order_id, oa.kind, oa.full_name, oa.company, oa.line1, oa.line2, oa.city, oa.region, oa.postal_code, oa.country, oa.phone, oa.created_at
+FROM order_addresses oa
+JOIN orders o ON o.id = oa.order_id
+WHERE o.user_id = $1
+ORDER BY oa.order_id, oa.kind;
+
+-- name: RequestAccountDeletion :one
+UPDATE users
+SET deletion_requested_at = COALESCE(deletion_requested_at, now()), updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING deletion_requested_at;
+
+-- name: CancelAccountDeletion :execrows
+UPDATE users
+SET deletion_requested_at = NULL, updated_at = now()
+WHERE id = $1 AND deletion_requested_at IS NOT NULL AND deleted_at IS NULL;
+
+-- name: ListAccountsDueForDeletion :many
+SELECT id
+FROM users
+WHERE deletion_requested_at <= sqlc.arg(before)::timestamptz AND deleted_at IS NULL
+ORDER BY id
+LIMIT sqlc.arg(batch_size)
+FOR UPDATE SKIP LOCKED;
+
+-- name: AnonymizeUser :exec
+UPDATE users
+SET email = 'deleted-' || id || '@deleted.invalid',
+    password_hash = '',
+    first_name = '',
+    last_name = '',
+    phone = '',
+    email_verified_at = NULL,
+    deleted_at = now(),
+    token_version = token_version + 1,
+    updated_at = now()
+WHERE id = $1;
+
+-- name: AnonymizeUserOrderAddresses :exec
+UPDATE order_addresses
+SET full_name = '', company = '', line1 = '', line2 = '', phone = ''
+WHERE order_id IN (SELECT id FROM orders WHERE user_id = $1);
+
+-- name: DeleteUserIdentities :exec
+DELETE FROM user_identities WHERE user_id = $1;
+
+-- name: DeleteUserAddresses :exec
+DELETE FROM addresses WHERE user_id = $1;
+
+-- name: DeleteUserRoles :exec
+DELETE FROM user_roles WHERE user_id = $1;
+
+-- name: DeleteUserCarts :exec
+DELETE FROM carts WHERE user_id = $1::bigint;
+
+-- name: DeleteUserRefreshTokens :exec
+DELETE FROM refresh_tokens WHERE user_id = $1;
+
+-- name: DeleteUserPasswordResetTokens :exec
+DELETE FROM password_reset_tokens WHERE user_id = $1;
+
+-- name: DeleteUserEmailChangeTokens :exec
+DELETE FROM email_change_tokens WHERE user_id = $1;
+
+-- name: DeleteUserEmailVerificationTokens :exec
+DELETE FROM email_verification_tokens WHERE user_id = $1;
+
+-- name: DeleteUserMfaChallenges :exec
+DELETE FROM mfa_challenges WHERE user_id = $1;
@@ -1,11 +1,12 @@
 -- name: GetOrCreateActiveCart :one
 WITH existing AS (
-  SELECT c.id FROM carts c WHERE c.user_id = $1 AND c.status = 'active' LIMIT 1
+  SELECT c.id FROM carts c WHERE c.user_id = $1::bigint AND c.status = 'active' LIMIT 1
 ),
 inserted AS (
   INSERT INTO carts (user_id, status)
-  SELECT $1, 'active'
+  SELECT $1::bigint, 'active'
   WHERE NOT EXISTS (SELECT 1 FROM existing)
+  ON CONFLICT (user_id) WHERE status = 'active' DO NOTHING
   RETURNING id
 )
 SELECT id FROM inserted
@@ -19,13 +20,25 @@ SELECT
   ci.product_id,
   ci.qty,
   p.name,
-  (p.price_cents::int) AS price_cents,
-  (p.price_cents::int * ci.qty)::int AS line_total_cents
+  p.price_cents,
+  p.currency,
+  (p.price_cents * ci.qty)::bigint AS line_total_cents,
+  (p.stock - COALESCE((
+    SELECT SUM(r.qty) FROM stock_reservations r
+    WHERE r.product_id = ci.product_id AND r.cart_id <> ci.cart_id AND r.expires_at > now()
+  ), 0))::int AS available
 FROM cart_items ci
 JOIN products p ON p.id = ci.product_id
 WHERE ci.cart_id = $1
 ORDER BY ci.id;
 
+-- name: GetCartCurrency :one
+SELECT p.currency
+FROM cart_items ci
+JOIN products p ON p.id = ci.product_id
+WHERE ci.cart_id = $1
+LIMIT 1;
+
 -- name: UpsertCartItem :one
 INSERT INTO cart_items (cart_id, product_id, qty)
 VALUES ($1, $2, $3)
@@ -42,21 +55,42 @@ WHERE id = $1;
 DELETE FROM cart_items WHERE id = $1;
 
 -- name: LockCartItemsForCheckout :many
-SELECT ci.product_id, ci.qty, p.price_cents, p.stock, p.is_active
+SELECT
+  ci.product_id,
+  ci.qty,
+  p.name,
+  p.price_cents,
+  p.currency,
+  p.stock,
+  p.is_active,
+  COALESCE((
+    SELECT SUM(r.qty) FROM stock_reservations r
+    WHERE r.product_id = p.id AND r.cart_id <> ci.cart_id AND r.expires_at > now()
+  ), 0)::int AS reserved_elsewhere
 FROM cart_items ci
 JOIN products p ON p.id = ci.product_id
 WHERE ci.cart_id = $1
+ORDER BY p.id
 FOR UPDATE;
 
--- name: DecrementProductStock :exec
+-- name: DecrementProductStock :execrows
 UPDATE products
 SET stock = stock - $2, updated_at = now()
 WHERE id = $1 AND stock >= $2;
 
+-- name: IncrementProductStock :exec
+UPDATE products
+SET stock = stock + $2, updated_at = now()
+WHERE id = $1;
+
 -- name: CreateOrder :one
-INSERT INTO orders (user_id, status, total_cents)
-VALUES ($1, 'placed', $2)
-RETURNING id;
+INSERT INTO orders (user_id, status, total_cents, currency)
+VALUES ($1, 'placed', $2, $3)
+RETURNING id, user_id, status, total_cents, created_at, currency;
+
+-- name: CreateOrderAddress :exec
+INSERT INTO order_addresses (order_id, kind, full_name, company, line1, line2, city, region, postal_code, country, phone)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
 
 -- name: CreateOrderItem :exec
 INSERT INTO order_items (order_id, product_id, unit_price_cents, qty, line_total_cents)
@@ -73,14 +107,19 @@ DELETE FROM cart_items WHERE cart_id = $1;
 -- name: GetActiveCartID :one
 SELECT id
 FROM carts
-WHERE user_id = $1 AND status = 'active'
+WHERE user_id = $1::bigint AND status = 'active'
 LIMIT 1;
 
--- name: UpdateCartItemQtyInCart :exec
+-- name: GetCartItem :one
+SELECT id, cart_id, product_id, qty
+FROM cart_items
+WHERE id = $1 AND cart_id = $2;
+
+-- name: UpdateCartItemQtyInCart :execrows
 UPDATE cart_items
 SET qty = $3, updated_at = now()
 WHERE id = $1 AND cart_id = $2;
 
--- name: DeleteCartItemInCart :exec
+-- name: DeleteCartItemInCart :execrows
 DELETE FROM cart_items
 WHERE id = $1 AND cart_id = $2;
\ No newline at end of file
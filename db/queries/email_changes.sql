@@ -0,0 +1,19 @@
+-- name: CreateEmailChangeToken :exec
+INSERT INTO email_change_tokens (user_id, new_email, token_hash, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: InvalidateEmailChangeTokens :exec
+UPDATE email_change_tokens
+SET used_at = now()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: ConsumeEmailChangeToken :one
+UPDATE email_change_tokens
+SET used_at = now()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > now()
+RETURNING user_id, new_email;
+
+-- name: DeleteExpiredEmailChangeTokens :execrows
+DELETE FROM email_change_tokens WHERE expires_at <= now();
@@ -0,0 +1,27 @@
+-- name: CreateGuestCart :one
+INSERT INTO carts (guest_token_hash, status)
+VALUES ($1::text, 'active')
+RETURNING id;
+
+-- name: GetGuestCartID :one
+SELECT id
+FROM carts
+WHERE guest_token_hash = $1::text AND status = 'active';
+
+-- name: LockGuestCart :one
+SELECT id
+FROM carts
+WHERE guest_token_hash = $1::text AND status = 'active'
+FOR UPDATE;
+
+-- name: DeleteCart :exec
+DELETE FROM carts WHERE id = $1;
+
+-- name: DeleteStaleGuestCarts :execrows
+DELETE FROM carts c
+WHERE c.user_id IS NULL
+  AND c.updated_at < sqlc.arg(before)::timestamptz
+  AND NOT EXISTS (
+    SELECT 1 FROM cart_items ci
+    WHERE ci.cart_id = c.id AND ci.updated_at >= sqlc.arg(before)::timestamptz
+  );
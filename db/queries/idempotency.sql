@@ -0,0 +1,28 @@
+-- name: DeleteStaleIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = sqlc.arg(scope)
+  AND key = sqlc.arg(key)
+  AND created_at < now() - make_interval(secs => sqlc.arg(ttl_seconds)::float);
+
+-- name: InsertIdempotencyKey :execrows
+INSERT INTO idempotency_keys (scope, key, request_hash)
+VALUES ($1, $2, $3)
+ON CONFLICT (scope, key) DO NOTHING;
+
+-- name: GetIdempotencyKey :one
+SELECT request_hash, status_code, content_type, response_headers, response_body
+FROM idempotency_keys
+WHERE scope = $1 AND key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code = $3, content_type = $4, response_headers = $5, response_body = $6, completed_at = now()
+WHERE scope = $1 AND key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE created_at < now() - make_interval(secs => sqlc.arg(ttl_seconds)::float);
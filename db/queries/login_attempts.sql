@@ -0,0 +1,29 @@
+-- name: GetLoginAttempts :one
+SELECT failures, last_failure_at, locked_until
+FROM login_attempts
+WHERE key = $1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_attempts (key, failures, last_failure_at)
+VALUES (sqlc.arg(key), 1, sqlc.arg(now))
+ON CONFLICT (key) DO UPDATE SET
+  failures = CASE
+    WHEN login_attempts.last_failure_at < sqlc.arg(now)::timestamptz - make_interval(secs => sqlc.arg(window_seconds)::float)
+    THEN 1
+    ELSE login_attempts.failures + 1
+  END,
+  last_failure_at = EXCLUDED.last_failure_at
+RETURNING failures, last_failure_at, locked_until;
+
+-- name: LockLoginKey :exec
+UPDATE login_attempts
+SET locked_until = $2
+WHERE key = $1;
+
+-- name: DeleteLoginAttempts :exec
+DELETE FROM login_attempts WHERE key = $1;
+
+-- name: DeleteStaleLoginAttempts :execrows
+DELETE FROM login_attempts
+WHERE last_failure_at < sqlc.arg(before)
+  AND (locked_until IS NULL OR locked_until < sqlc.arg(before));
@@ -0,0 +1,63 @@
+-- name: GetUserTotp :one
+SELECT secret, confirmed_at, last_used_step
+FROM user_totp
+WHERE user_id = $1;
+
+-- name: UpsertPendingTotp :exec
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = 0, created_at = now();
+
+-- name: ConfirmTotp :exec
+UPDATE user_totp
+SET confirmed_at = now(), last_used_step = $2
+WHERE user_id = $1;
+
+-- name: UseTotpStep :execrows
+UPDATE user_totp
+SET last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2;
+
+-- name: DeleteUserTotp :exec
+DELETE FROM user_totp WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE user_id = $1;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*)::int AS remaining
+FROM recovery_codes
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: CreateMfaChallenge :exec
+INSERT INTO mfa_challenges (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: GetMfaChallengeForUpdate :one
+SELECT id, user_id, attempts, expires_at, used_at
+FROM mfa_challenges
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: IncrementMfaChallengeAttempts :exec
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE id = $1;
+
+-- name: MarkMfaChallengeUsed :exec
+UPDATE mfa_challenges
+SET used_at = now()
+WHERE id = $1;
+
+-- name: DeleteExpiredMfaChallenges :execrows
+DELETE FROM mfa_challenges WHERE expires_at <= now();
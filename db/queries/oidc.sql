@@ -0,0 +1,21 @@
+-- name: CreateOidcLoginState :exec
+INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: TakeOidcLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1 AND provider = $2
+RETURNING code_verifier, nonce, expires_at;
+
+-- name: DeleteExpiredOidcLoginStates :execrows
+DELETE FROM oidc_login_states
+WHERE expires_at < now();
+
+-- name: GetUserIdentity :one
+SELECT user_id
+FROM user_identities
+WHERE provider = $1 AND subject = $2;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (user_id, provider, subject, email)
+VALUES ($1, $2, $3, $4);
@@ -0,0 +1,58 @@
+-- name: ListOrdersForUser :many
+SELECT id, user_id, status, total_cents, created_at, currency
+FROM orders
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.arg(status)::text = '' OR status = sqlc.arg(status)::text)
+  AND (sqlc.arg(before_id)::bigint = 0 OR id < sqlc.arg(before_id)::bigint)
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetOrderForUser :one
+SELECT id, user_id, status, total_cents, created_at, currency
+FROM orders
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListOrderItems :many
+SELECT
+  oi.id,
+  oi.product_id,
+  p.name,
+  oi.unit_price_cents,
+  oi.qty,
+  oi.line_total_cents
+FROM order_items oi
+JOIN products p ON p.id = oi.product_id
+WHERE oi.order_id = $1
+ORDER BY oi.id;
+
+-- name: ListOrderAddresses :many
+SELECT order_id, kind, full_name, company, line1, line2, city, region, postal_code, country, phone, created_at
+FROM order_addresses
+WHERE order_id = $1
+ORDER BY kind;
+
+-- name: GetOrder :one
+SELECT id, user_id, status, total_cents, created_at, currency
+FROM orders
+WHERE id = $1;
+
+-- name: GetOrderForUpdate :one
+SELECT id, user_id, status, total_cents, created_at, currency
+FROM orders
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateOrderStatus :exec
+UPDATE orders
+SET status = $2
+WHERE id = $1;
+
+-- name: InsertOrderStatusHistory :exec
+INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, reason)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListOrderStatusHistory :many
+SELECT id, order_id, from_status, to_status, changed_by, reason, created_at
+FROM order_status_history
+WHERE order_id = $1
+ORDER BY id;
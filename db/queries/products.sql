@@ -0,0 +1,117 @@
+-- name: GetActiveProduct :one
+SELECT id, name, description, price_cents, stock, is_active, created_at, updated_at, currency,
+  (stock - COALESCE((
+    SELECT SUM(r.qty) FROM stock_reservations r
+    WHERE r.product_id = products.id AND r.expires_at > now()
+  ), 0))::int AS available
+FROM products
+WHERE id = $1 AND is_active;
+
+-- name: ListProductsNewest :many
+SELECT id, name, description, price_cents, stock, is_active, created_at, updated_at, currency,
+  (stock - COALESCE((
+    SELECT SUM(r.qty) FROM stock_reservations r
+    WHERE r.product_id = products.id AND r.expires_at > now()
+  ), 0))::int AS available
+FROM products
+WHERE is_active
+  AND (sqlc.arg(search)::text = ''
+    OR name ILIKE '%' || sqlc.arg(search)::text || '%'
+    OR description ILIKE '%' || sqlc.arg(search)::text || '%')
+  AND (sqlc.narg(min_price)::bigint IS NULL OR price_cents >= sqlc.narg(min_price)::bigint)
+  AND (sqlc.narg(max_price)::bigint IS NULL OR price_cents <= sqlc.narg(max_price)::bigint)
+  AND (sqlc.arg(currency)::text = '' OR currency = sqlc.arg(currency)::text)
+  AND (sqlc.arg(after_id)::bigint = 0 OR id < sqlc.arg(after_id)::bigint)
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListProductsPriceAsc :many
+SELECT id, name, description, price_cents, stock, is_active, created_at, updated_at, currency,
+  (stock - COALESCE((
+    SELECT SUM(r.qty) FROM stock_reservations r
+    WHERE r.product_id = products.id AND r.expires_at > now()
+  ), 0))::int AS available
+FROM products
+WHERE is_active
+  AND (sqlc.arg(search)::text = ''
+    OR name ILIKE '%' || sqlc.arg(search)::text || '%'
+    OR description ILIKE '%' || sqlc.arg(search)::text || '%')
+  AND (sqlc.narg(min_price)::bigint IS NULL OR price_cents >= sqlc.narg(min_price)::bigint)
+  AND (sqlc.narg(max_price)::bigint IS NULL OR price_cents <= sqlc.narg(max_price)::bigint)
+  AND (sqlc.arg(currency)::text = '' OR currency = sqlc.arg(currency)::text)
+  AND (sqlc.narg(after_price)::bigint IS NULL
+    OR (price_cents, id) > (sqlc.narg(after_price)::bigint, sqlc.arg(after_id)::bigint))
+ORDER BY price_cents, id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListProductsPriceDesc :many
+SELECT id, name, description, price_cents, stock, is_active, created_at, updated_at, currency,
+  (stock - COALESCE((
+    SELECT SUM(r.qty) FROM stock_reservations r
+    WHERE r.product_id = products.id AND r.expires_at > now()
+  ), 0))::int AS available
+FROM products
+WHERE is_active
+  AND (sqlc.arg(search)::text = ''
+    OR name ILIKE '%' || sqlc.arg(search)::text || '%'
+    OR description ILIKE '%' || sqlc.arg(search)::text || '%')
+  AND (sqlc.narg(min_price)::bigint IS NULL OR price_cents >= sqlc.narg(min_price)::bigint)
+  AND (sqlc.narg(max_price)::bigint IS NULL OR price_cents <= sqlc.narg(max_price)::bigint)
+  AND (sqlc.arg(currency)::text = '' OR currency = sqlc.arg(currency)::text)
+  AND (sqlc.narg(after_price)::bigint IS NULL
+    OR (price_cents, id) < (sqlc.narg(after_price)::bigint, sqlc.arg(after_id)::bigint))
+ORDER BY price_cents DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListProductsName :many
+SELECT id, name, description, price_cents, stock, is_active, created_at, updated_at, currency,
+  (stock - COALESCE((
+    SELECT SUM(r.qty) FROM stock_reservations r
+    WHERE r.product_id = products.id AND r.expires_at > now()
+  ), 0))::int AS available
+FROM products
+WHERE is_active
+  AND (sqlc.arg(search)::text = ''
+    OR name ILIKE '%' || sqlc.arg(search)::text || '%'
+    OR description ILIKE '%' || sqlc.arg(search)::text || '%')
+  AND (sqlc.narg(min_price)::bigint IS NULL OR price_cents >= sqlc.narg(min_price)::bigint)
+  AND (sqlc.narg(max_price)::bigint IS NULL OR price_cents <= sqlc.narg(max_price)::bigint)
+  AND (sqlc.arg(currency)::text = '' OR currency = sqlc.arg(currency)::text)
+  AND (sqlc.narg(after_name)::text IS NULL
+    OR (name, id) > (sqlc.narg(after_name)::text, sqlc.arg(after_id)::bigint))
+ORDER BY name, id
+LIMIT sqlc.arg(page_size);
+
+-- name: LockActiveProduct :one
+SELECT id, name, description, price_cents, stock, is_active, created_at, updated_at, currency
+FROM products
+WHERE id = $1 AND is_active
+FOR UPDATE;
+
+-- name: GetProduct :one
+SELECT id, name, description, price_cents, stock, is_active, created_at, updated_at, currency
+FROM products
+WHERE id = $1;
+
+-- name: CreateProduct :one
+INSERT INTO products (name, description, price_cents, currency, stock)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, name, description, price_cents, stock, is_active, created_at, updated_at, currency;
+
+-- name: UpdateProduct :one
+UPDATE products
+SET
+  name = COALESCE(sqlc.narg(name), name),
+  description = COALESCE(sqlc.narg(description), description),
+  price_cents = COALESCE(sqlc.narg(price_cents), price_cents),
+  currency = COALESCE(sqlc.narg(currency), currency),
+  stock = COALESCE(sqlc.narg(stock), stock),
+  is_active = COALESCE(sqlc.narg(is_active), is_active),
+  updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING id, name, description, price_cents, stock, is_active, created_at, updated_at, currency;
+
+-- name: DeactivateProduct :execrows
+UPDATE products
+SET is_active = FALSE, updated_at = now()
+WHERE id = $1;
@@ -0,0 +1,78 @@
+-- name: GetUserProfile :one
+SELECT id, email, first_name, last_name, phone
+FROM users
+WHERE id = $1;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET first_name = $2, last_name = $3, phone = $4, updated_at = now()
+WHERE id = $1
+RETURNING id, email, first_name, last_name, phone;
+
+-- name: LockUserAddresses :one
+SELECT id
+FROM users
+WHERE id = sqlc.arg(user_id)
+FOR UPDATE;
+
+-- name: CountAddresses :one
+SELECT COUNT(*)
+FROM addresses
+WHERE user_id = $1;
+
+-- name: ListAddresses :many
+SELECT id, user_id, full_name, company, line1, line2, city, region, postal_code, country, phone, is_default_shipping, is_default_billing, created_at, updated_at
+FROM addresses
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: GetAddress :one
+SELECT id, user_id, full_name, company, line1, line2, city, region, postal_code, country, phone, is_default_shipping, is_default_billing, created_at, updated_at
+FROM addresses
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetDefaultShippingAddress :one
+SELECT id, user_id, full_name, company, line1, line2, city, region, postal_code, country, phone, is_default_shipping, is_default_billing, created_at, updated_at
+FROM addresses
+WHERE user_id = $1 AND is_default_shipping;
+
+-- name: GetDefaultBillingAddress :one
+SELECT id, user_id, full_name, company, line1, line2, city, region, postal_code, country, phone, is_default_shipping, is_default_billing, created_at, updated_at
+FROM addresses
+WHERE user_id = $1 AND is_default_billing;
+
+-- name: CreateAddress :one
+INSERT INTO addresses (user_id, full_name, company, line1, line2, city, region, postal_code, country, phone, is_default_shipping, is_default_billing)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, user_id, full_name, company, line1, line2, city, region, postal_code, country, phone, is_default_shipping, is_default_billing, created_at, updated_at;
+
+-- name: UpdateAddress :one
+UPDATE addresses
+SET full_name = $3,
+    company = $4,
+    line1 = $5,
+    line2 = $6,
+    city = $7,
+    region = $8,
+    postal_code = $9,
+    country = $10,
+    phone = $11,
+    is_default_shipping = $12,
+    is_default_billing = $13,
+    updated_at = now()
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, full_name, company, line1, line2, city, region, postal_code, country, phone, is_default_shipping, is_default_billing, created_at, updated_at;
+
+-- name: DeleteAddress :execrows
+DELETE FROM addresses
+WHERE id = $1 AND user_id = $2;
+
+-- name: ClearDefaultShippingAddress :exec
+UPDATE addresses
+SET is_default_shipping = false, updated_at = now()
+WHERE user_id = $1 AND is_default_shipping;
+
+-- name: ClearDefaultBillingAddress :exec
+UPDATE addresses
+SET is_default_billing = false, updated_at = now()
+WHERE user_id = $1 AND is_default_billing;
@@ -0,0 +1,34 @@
+-- name: ListUserPermissions :many
+SELECT DISTINCT rp.permission
+FROM user_roles ur
+JOIN role_permissions rp ON rp.role = ur.role
+WHERE ur.user_id = $1
+ORDER BY rp.permission;
+
+-- name: ListUserRoles :many
+SELECT role
+FROM user_roles
+WHERE user_id = $1
+ORDER BY role;
+
+-- name: ListRoles :many
+SELECT name, description
+FROM roles
+ORDER BY name;
+
+-- name: ListRolePermissions :many
+SELECT role, permission
+FROM role_permissions
+ORDER BY role, permission;
+
+-- name: RoleExists :one
+SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1) AS found;
+
+-- name: AddUserRole :execrows
+INSERT INTO user_roles (user_id, role)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveUserRole :execrows
+DELETE FROM user_roles
+WHERE user_id = $1 AND role = $2;
@@ -0,0 +1,36 @@
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetRefreshTokenForUpdate :one
+SELECT id, user_id, family_id, expires_at, used_at, revoked_at
+FROM refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: MarkRefreshTokenUsed :exec
+UPDATE refresh_tokens
+SET used_at = now()
+WHERE id = $1;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamilyByToken :exec
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE family_id = (
+    SELECT rt.family_id FROM refresh_tokens rt
+    WHERE rt.token_hash = $1 AND rt.user_id = $2
+  )
+  AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteExpiredRefreshTokens :execrows
+DELETE FROM refresh_tokens WHERE expires_at <= now();
@@ -0,0 +1,29 @@
+-- name: SumReservedElsewhere :one
+SELECT COALESCE(SUM(qty), 0)::int AS reserved
+FROM stock_reservations
+WHERE product_id = $1 AND cart_id <> $2 AND expires_at > now();
+
+-- name: UpsertReservation :exec
+INSERT INTO stock_reservations (cart_id, product_id, qty, expires_at)
+VALUES (
+  sqlc.arg(cart_id),
+  sqlc.arg(product_id),
+  sqlc.arg(qty),
+  now() + make_interval(secs => sqlc.arg(ttl_seconds)::float)
+)
+ON CONFLICT (cart_id, product_id)
+DO UPDATE SET qty = EXCLUDED.qty, expires_at = EXCLUDED.expires_at, updated_at = now();
+
+-- name: DeleteReservationForCartItem :exec
+DELETE FROM stock_reservations r
+USING cart_items ci
+WHERE ci.id = $1
+  AND ci.cart_id = $2
+  AND r.cart_id = ci.cart_id
+  AND r.product_id = ci.product_id;
+
+-- name: DeleteCartReservations :exec
+DELETE FROM stock_reservations WHERE cart_id = $1;
+
+-- name: DeleteExpiredReservations :execrows
+DELETE FROM stock_reservations WHERE expires_at <= now();
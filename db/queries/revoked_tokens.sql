@@ -0,0 +1,14 @@
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, user_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: GetTokenState :one
+SELECT
+  u.token_version,
+  EXISTS (SELECT 1 FROM revoked_tokens rt WHERE rt.jti = $2) AS revoked
+FROM users u
+WHERE u.id = $1;
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens WHERE expires_at <= now();
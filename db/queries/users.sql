@@ -4,16 +4,47 @@ VALUES ($1, $2)
 RETURNING id;
 
 -- name: GetUserByEmail :one
-SELECT id, email, password_hash, role
+SELECT id, email, password_hash, token_version
 FROM users
 WHERE email = $1;
 
 -- name: GetUserByID :one
-SELECT id, email, role
+SELECT id, email, token_version, email_verified_at
 FROM users
 WHERE id = $1;
 
 -- name: UpdateUserPassword :exec
 UPDATE users
 SET password_hash = $2, updated_at = now()
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: BumpTokenVersion :one
+UPDATE users
+SET token_version = token_version + 1, updated_at = now()
+WHERE id = $1
+RETURNING token_version;
+
+-- name: GetUserPasswordHash :one
+SELECT password_hash
+FROM users
+WHERE id = $1;
+
+-- name: UpdateUserEmail :exec
+UPDATE users
+SET email = $2, email_verified_at = now(), updated_at = now()
+WHERE id = $1;
+
+-- name: MarkEmailVerified :exec
+UPDATE users
+SET email_verified_at = now(), updated_at = now()
+WHERE id = $1 AND email_verified_at IS NULL;
+
+-- name: IsEmailVerified :one
+SELECT email_verified_at IS NOT NULL AS verified
+FROM users
+WHERE id = $1;
+
+-- name: CreateExternalUser :one
+INSERT INTO users (email, password_hash, email_verified_at)
+VALUES ($1, '', now())
+RETURNING id;
@@ -1,16 +1,30 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/angelchiav/go-ecommerce/internal/config"
 	"github.com/angelchiav/go-ecommerce/internal/db"
 	"github.com/angelchiav/go-ecommerce/internal/handlers"
 	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/jwtkeys"
+	"github.com/angelchiav/go-ecommerce/internal/mail"
+	"github.com/angelchiav/go-ecommerce/internal/oidc"
 	"github.com/angelchiav/go-ecommerce/internal/service"
 	"github.com/angelchiav/go-ecommerce/internal/sqlc"
 )
 
+// verifiableFeatures can be listed in REQUIRE_VERIFIED_EMAIL.
+var verifiableFeatures = map[string]bool{
+	"cart":     true,
+	"checkout": true,
+	"orders":   true,
+}
+
 type App struct {
 	handler http.Handler
 }
@@ -25,26 +39,179 @@ func New(cfg config.Config) (*App, error) {
 
 	q := sqlc.New(conn)
 
-	cartSvc := service.NewCartService(conn, q)
-	cartH := handlers.NewCart(cartSvc)
+	cartSvc := service.NewCartService(conn, q, cfg.ReservationTTL, cfg.GuestCartTTL)
+	cartCookie := handlers.CartCookie{
+		TTL:    cfg.GuestCartTTL,
+		Secure: strings.HasPrefix(cfg.AppURL, "https://"),
+	}
+	cartH := handlers.NewCart(cartSvc, cartCookie)
+
+	checkoutSvc := service.NewCheckoutService(conn, q)
+	checkoutH := handlers.NewCheckout(checkoutSvc)
+
+	orderSvc := service.NewOrderService(conn, q)
+	orderH := handlers.NewOrders(orderSvc)
+	adminOrderH := handlers.NewAdminOrders(orderSvc)
+
+	productSvc := service.NewProductService(q)
+	productH := handlers.NewProducts(productSvc)
+	adminProductH := handlers.NewAdminProducts(productSvc)
+
+	keys, err := jwtkeys.Load(cfg.JWTKeysDir, cfg.JWTSigningKeyID, cfg.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+	jwksH := handlers.NewJWKS(keys)
 
-	authSvc := service.NewAuthService(q, cfg.JWTSecret)
-	authH := handlers.NewAuth(authSvc, q)
-	authMW := httpx.AuthJWT(cfg.JWTSecret)
+	mailer, err := mail.New(cfg.Mailer, cfg.MailDir, cfg.MailFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	var attempts service.AttemptStore
+	switch cfg.LoginAttemptStore {
+	case "postgres":
+		attempts = service.NewPostgresAttemptStore(q)
+	case "memory":
+		attempts = service.NewMemoryAttemptStore()
+	default:
+		return nil, fmt.Errorf("LOGIN_ATTEMPT_STORE: unknown store %q", cfg.LoginAttemptStore)
+	}
+	accountPolicy := service.DefaultAccountPolicy
+	accountPolicy.LockAfter = cfg.LoginLockoutAfter
+	accountPolicy.LockFor = cfg.LoginLockoutDuration
+	guard := service.NewLoginGuard(attempts, accountPolicy, service.DefaultIPPolicy, nil)
+
+	revocations := service.NewRevocationStore(q)
+	authSvc := service.NewAuthService(conn, q, revocations, keys, mailer, guard, service.AuthOptions{
+		AccessTTL:        cfg.AccessTokenTTL,
+		RefreshTTL:       cfg.RefreshTokenTTL,
+		PasswordResetTTL: cfg.PasswordResetTTL,
+		EmailChangeTTL:   cfg.EmailChangeTTL,
+		VerificationTTL:  cfg.VerificationTTL,
+		AppURL:           cfg.AppURL,
+		MFAIssuer:        cfg.MFAIssuer,
+		DeletionGrace:    cfg.AccountDeletionGrace,
+
+		VerificationResendInterval: cfg.VerificationResendInterval,
+	})
+
+	// REQUIRE_VERIFIED_EMAIL names the features only verified users may use
+	verifiedOnly := map[string]bool{}
+	for _, f := range cfg.RequireVerifiedEmail {
+		if !verifiableFeatures[f] {
+			return nil, fmt.Errorf("REQUIRE_VERIFIED_EMAIL: unknown feature %q", f)
+		}
+		verifiedOnly[f] = true
+	}
+	// signing in must not smuggle a guest cart past the "cart" gate
+	authH := handlers.NewAuth(authSvc, q, cartSvc, cartCookie, verifiedOnly["cart"])
+	adminUserH := handlers.NewAdminUsers(authSvc)
+	privacyH := handlers.NewPrivacy(authSvc, service.NewDataExportService(q))
+	profileH := handlers.NewProfile(service.NewProfileService(q), service.NewAddressService(conn, q))
+
+	var providers []*oidc.Provider
+	for _, p := range cfg.OIDCProviders {
+		providers = append(providers, oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  cfg.AppURL + "/v1/auth/oidc/" + p.Name + "/callback",
+			Scopes:       p.Scopes,
+		}, nil))
+	}
+	oidcH := handlers.NewOIDC(service.NewOIDCLogin(conn, q, authSvc, providers))
+	authMW := httpx.AuthJWT(keys, revocations)
+	optionalAuthMW := httpx.OptionalAuthJWT(keys, revocations)
+	adminMW := func(perm string, next http.HandlerFunc) http.HandlerFunc {
+		return authMW(httpx.RequirePermission(perm)(next))
+	}
+	adminRoleH := handlers.NewAdminRoles(service.NewRoleService(conn, q, revocations))
+
+	requireVerified := httpx.RequireVerifiedEmail(authSvc)
+	gate := func(feature string, next http.HandlerFunc) http.HandlerFunc {
+		if verifiedOnly[feature] {
+			return requireVerified(next)
+		}
+		return next
+	}
+
+	idemStore := service.NewIdempotencyStore(q, cfg.IdempotencyKeyTTL)
+	idem := httpx.Idempotency(idempotencyStore{idemStore})
 
 	// PUBLIC
 	r.Handle("GET", "/health", health.Get)
+	r.Handle("GET", "/.well-known/jwks.json", jwksH.Get)
 	r.Handle("POST", "/v1/auth/register", authH.Register)
 	r.Handle("POST", "/v1/auth/login", authH.Login)
+	r.Handle("POST", "/v1/auth/refresh", authH.Refresh)
+	r.Handle("POST", "/v1/auth/password/forgot", authH.ForgotPassword)
+	r.Handle("POST", "/v1/auth/password/reset", authH.ResetPassword)
+	r.Handle("POST", "/v1/auth/email/confirm", authH.ConfirmEmail)
+	r.Handle("POST", "/v1/auth/verify-email", authH.VerifyEmail)
+	r.Handle("POST", "/v1/auth/mfa/verify", authH.VerifyMFA)
+	r.Handle("GET", "/v1/auth/oidc/{provider}", oidcH.Start)
+	r.Handle("GET", "/v1/auth/oidc/{provider}/callback", oidcH.Callback)
+	r.Handle("GET", "/v1/products", productH.List)
+	r.Handle("GET", "/v1/products/{id}", productH.Get)
+	// carts work for guests too, keyed by their cart token
+	r.Handle("GET", "/v1/cart", optionalAuthMW(cartH.Get))
+	r.Handle("POST", "/v1/cart/items", optionalAuthMW(gate("cart", idem(cartH.AddItem))))
+	r.Handle("PATCH", "/v1/cart/items/{id}", optionalAuthMW(gate("cart", idem(cartH.UpdateItemQty))))
+	r.Handle("DELETE", "/v1/cart/items/{id}", optionalAuthMW(gate("cart", idem(cartH.DeleteItem))))
 
 	// PRIVATE
 	r.Handle("GET", "/v1/me", authMW(authH.Me))
-	r.Handle("GET", "/v1/cart", authMW(cartH.Get))
-	r.Handle("POST", "/v1/cart/items", authMW(cartH.AddItem))
-	r.Handle("PATCH", "/v1/cart/items/{id}", authMW(cartH.UpdateItemQty))
-	r.Handle("DELETE", "/v1/cart/items/{id}", authMW(cartH.DeleteItem))
+	r.Handle("DELETE", "/v1/me", authMW(privacyH.DeleteAccount))
+	r.Handle("GET", "/v1/me/export", authMW(privacyH.Export))
+	r.Handle("GET", "/v1/me/profile", authMW(profileH.Get))
+	r.Handle("PUT", "/v1/me/profile", authMW(profileH.Update))
+	r.Handle("GET", "/v1/me/addresses", authMW(profileH.ListAddresses))
+	r.Handle("POST", "/v1/me/addresses", authMW(profileH.CreateAddress))
+	r.Handle("GET", "/v1/me/addresses/{id}", authMW(profileH.GetAddress))
+	r.Handle("PUT", "/v1/me/addresses/{id}", authMW(profileH.UpdateAddress))
+	r.Handle("DELETE", "/v1/me/addresses/{id}", authMW(profileH.DeleteAddress))
+	r.Handle("PUT", "/v1/me/password", authMW(authH.ChangePassword))
+	r.Handle("PUT", "/v1/me/email", authMW(authH.ChangeEmail))
+	r.Handle("POST", "/v1/me/mfa/totp", authMW(authH.EnrolTOTP))
+	r.Handle("POST", "/v1/me/mfa/totp/confirm", authMW(authH.ConfirmTOTP))
+	r.Handle("DELETE", "/v1/me/mfa/totp", authMW(authH.DisableTOTP))
+	r.Handle("POST", "/v1/me/mfa/recovery-codes", authMW(authH.RegenerateRecoveryCodes))
+	r.Handle("POST", "/v1/auth/verify-email/resend", authMW(authH.ResendVerification))
+	r.Handle("POST", "/v1/auth/logout", authMW(authH.Logout))
+	r.Handle("POST", "/v1/auth/logout-all", authMW(authH.LogoutAll))
+	r.Handle("POST", "/v1/checkout", authMW(gate("checkout", idem(checkoutH.Checkout))))
+	r.Handle("GET", "/v1/orders", authMW(orderH.List))
+	r.Handle("GET", "/v1/orders/{id}", authMW(orderH.Get))
+	r.Handle("POST", "/v1/orders/{id}/cancel", authMW(gate("orders", idem(orderH.Cancel))))
+
+	// ADMIN
+	r.Handle("POST", "/v1/admin/products", adminMW("products:write", adminProductH.Create))
+	r.Handle("PATCH", "/v1/admin/products/{id}", adminMW("products:write", adminProductH.Update))
+	r.Handle("DELETE", "/v1/admin/products/{id}", adminMW("products:write", adminProductH.Delete))
+	r.Handle("POST", "/v1/admin/orders/{id}/status", adminMW("orders:write", adminOrderH.UpdateStatus))
+	r.Handle("GET", "/v1/admin/orders/{id}/history", adminMW("orders:read", adminOrderH.History))
+	r.Handle("POST", "/v1/admin/users/{id}/unlock", adminMW("users:write", adminUserH.Unlock))
+	r.Handle("GET", "/v1/admin/roles", adminMW("roles:read", adminRoleH.List))
+	r.Handle("GET", "/v1/admin/users/{id}/roles", adminMW("roles:read", adminRoleH.UserRoles))
+	r.Handle("POST", "/v1/admin/users/{id}/roles", adminMW("roles:write", adminRoleH.Assign))
+	r.Handle("DELETE", "/v1/admin/users/{id}/roles/{role}", adminMW("roles:write", adminRoleH.Remove))
+
+	go service.NewSweeper("reservation", cfg.ReservationSweepInterval, q.DeleteExpiredReservations).Run(context.Background())
+	go service.NewSweeper("idempotency key", time.Hour, idemStore.Purge).Run(context.Background())
+	go service.NewSweeper("refresh token", time.Hour, q.DeleteExpiredRefreshTokens).Run(context.Background())
+	go service.NewSweeper("revoked token", time.Hour, revocations.Purge).Run(context.Background())
+	go service.NewSweeper("password reset token", time.Hour, q.DeleteExpiredPasswordResetTokens).Run(context.Background())
+	go service.NewSweeper("email change token", time.Hour, q.DeleteExpiredEmailChangeTokens).Run(context.Background())
+	go service.NewSweeper("email verification token", time.Hour, q.DeleteExpiredEmailVerificationTokens).Run(context.Background())
+	go service.NewSweeper("oidc login state", time.Hour, q.DeleteExpiredOidcLoginStates).Run(context.Background())
+	go service.NewSweeper("mfa challenge", time.Hour, q.DeleteExpiredMfaChallenges).Run(context.Background())
+	go service.NewSweeper("guest cart", time.Hour, cartSvc.PurgeStaleGuestCarts).Run(context.Background())
+	go service.NewSweeper("account deletion", time.Hour, authSvc.PurgeDeletedAccounts).Run(context.Background())
+	go service.NewSweeper("login attempt", time.Hour, guard.Purge).Run(context.Background())
 
-	h := httpx.Recover(httpx.Logger(r))
+	h := httpx.Recover(httpx.RealIP(cfg.ClientIPHeader)(httpx.Logger(r)))
 
 	return &App{handler: h}, nil
 }
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+// idempotencyStore lets the Postgres-backed service.IdempotencyStore serve
+// the httpx.Idempotency middleware without either package importing the
+// other.
+type idempotencyStore struct {
+	*service.IdempotencyStore
+}
+
+func (s idempotencyStore) Begin(ctx context.Context, scope, key, requestHash string) (*httpx.IdempotencyRecord, bool, error) {
+	rec, claimed, err := s.IdempotencyStore.Begin(ctx, scope, key, requestHash)
+	if rec == nil {
+		return nil, claimed, err
+	}
+	return &httpx.IdempotencyRecord{
+		RequestHash: rec.RequestHash,
+		StatusCode:  rec.StatusCode,
+		ContentType: rec.ContentType,
+		Header:      http.Header(rec.Header),
+		Body:        rec.Body,
+	}, claimed, err
+}
+
+func (s idempotencyStore) Complete(ctx context.Context, scope, key string, rec httpx.IdempotencyRecord) error {
+	return s.IdempotencyStore.Complete(ctx, scope, key, service.IdempotencyRecord{
+		RequestHash: rec.RequestHash,
+		StatusCode:  rec.StatusCode,
+		ContentType: rec.ContentType,
+		Header:      rec.Header,
+		Body:        rec.Body,
+	})
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -11,6 +14,48 @@ type Config struct {
 	Addr      string
 	DBURL     string
 	JWTSecret string
+
+	JWTKeysDir      string
+	JWTSigningKeyID string
+
+	AccessTokenTTL   time.Duration
+	RefreshTokenTTL  time.Duration
+	PasswordResetTTL time.Duration
+	EmailChangeTTL   time.Duration
+
+	VerificationTTL            time.Duration
+	VerificationResendInterval time.Duration
+	RequireVerifiedEmail       []string
+
+	LoginAttemptStore    string
+	LoginLockoutAfter    int
+	LoginLockoutDuration time.Duration
+	ClientIPHeader       string
+	MFAIssuer            string
+
+	OIDCProviders []OIDCProvider
+
+	AccountDeletionGrace time.Duration
+
+	AppURL   string
+	Mailer   string
+	MailDir  string
+	MailFrom string
+
+	ReservationTTL           time.Duration
+	ReservationSweepInterval time.Duration
+	IdempotencyKeyTTL        time.Duration
+	GuestCartTTL             time.Duration
+}
+
+// OIDCProvider is an OpenID provider users can sign in with, configured
+// through OIDC_<NAME>_* variables.
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
 }
 
 func Load() Config {
@@ -19,7 +64,39 @@ func Load() Config {
 	return Config{
 		Addr:      env("ADDR", ":8080"),
 		DBURL:     mustEnv("DB_URL"),
-		JWTSecret: mustEnv("JWT_SECRET"),
+		JWTSecret: env("JWT_SECRET", ""),
+
+		JWTKeysDir:      env("JWT_KEYS_DIR", ""),
+		JWTSigningKeyID: env("JWT_SIGNING_KEY_ID", ""),
+
+		AccessTokenTTL:   envDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:  envDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		PasswordResetTTL: envDuration("PASSWORD_RESET_TTL", time.Hour),
+		EmailChangeTTL:   envDuration("EMAIL_CHANGE_TTL", 24*time.Hour),
+
+		VerificationTTL:            envDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		VerificationResendInterval: envDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute),
+		RequireVerifiedEmail:       envList("REQUIRE_VERIFIED_EMAIL", "checkout"),
+
+		LoginAttemptStore:    env("LOGIN_ATTEMPT_STORE", "postgres"),
+		LoginLockoutAfter:    envInt("LOGIN_LOCKOUT_AFTER", 10),
+		LoginLockoutDuration: envDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		ClientIPHeader:       env("CLIENT_IP_HEADER", ""),
+		MFAIssuer:            env("MFA_ISSUER", "go-ecommerce"),
+
+		OIDCProviders: oidcProviders(),
+
+		AccountDeletionGrace: envDuration("ACCOUNT_DELETION_GRACE", 30*24*time.Hour),
+
+		AppURL:   strings.TrimSuffix(env("APP_URL", "http://localhost:8080"), "/"),
+		Mailer:   env("MAILER", "log"),
+		MailDir:  env("MAIL_DIR", "tmp/mail"),
+		MailFrom: env("MAIL_FROM", "no-reply@localhost"),
+
+		ReservationTTL:           envDuration("RESERVATION_TTL", 15*time.Minute),
+		ReservationSweepInterval: envDuration("RESERVATION_SWEEP_INTERVAL", time.Minute),
+		IdempotencyKeyTTL:        envDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		GuestCartTTL:             envDuration("GUEST_CART_TTL", 30*24*time.Hour),
 	}
 }
 
@@ -57,3 +134,59 @@ func mustEnv(k string) string {
 	}
 	return v
 }
+
+func envDuration(k string, fallback time.Duration) time.Duration {
+	v := os.Getenv(k)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		panic("invalid duration in env var " + k)
+	}
+	return d
+}
+
+func envInt(k string, fallback int) int {
+	v := os.Getenv(k)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		panic("invalid integer in env var " + k)
+	}
+	return n
+}
+
+// envList splits a comma-separated value, dropping blanks. Set the variable
+// to "none" for an empty list.
+func envList(k, fallback string) []string {
+	v := env(k, fallback)
+	if v == "none" {
+		return nil
+	}
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// oidcProviders reads the providers named in OIDC_PROVIDERS.
+func oidcProviders() []OIDCProvider {
+	var out []OIDCProvider
+	for _, name := range envList("OIDC_PROVIDERS", "none") {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		out = append(out, OIDCProvider{
+			Name:         name,
+			Issuer:       mustEnv(prefix + "ISSUER"),
+			ClientID:     mustEnv(prefix + "CLIENT_ID"),
+			ClientSecret: env(prefix+"CLIENT_SECRET", ""),
+			Scopes:       envList(prefix+"SCOPES", "email,profile"),
+		})
+	}
+	return out
+}
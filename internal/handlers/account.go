@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type changePasswordReq struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type changeEmailReq struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type confirmEmailReq struct {
+	Token string `json:"token"`
+}
+
+type verifyEmailReq struct {
+	Token string `json:"token"`
+}
+
+func (h *Auth) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req changePasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	pair, err := h.auth.ChangePassword(r.Context(), httpx.MustUserID(r), req.CurrentPassword, req.NewPassword)
+	if err == service.ErrPasswordTooShort {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err == service.ErrWrongPassword {
+		httpx.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("PUT /v1/me/password error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, pair)
+}
+
+func (h *Auth) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	var req changeEmailReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	err := h.auth.RequestEmailChange(r.Context(), httpx.MustUserID(r), req.Email, req.Password)
+	if err == service.ErrEmailRequired || err == service.ErrEmailUnchanged {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err == service.ErrWrongPassword {
+		httpx.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err == service.ErrEmailTaken {
+		httpx.Error(w, http.StatusConflict, "email_taken")
+		return
+	}
+	if err != nil {
+		log.Printf("PUT /v1/me/email error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusAccepted, map[string]any{"status": "confirmation_sent"})
+}
+
+func (h *Auth) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	var req confirmEmailReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	err := h.auth.ConfirmEmailChange(r.Context(), req.Token)
+	if err == service.ErrEmailChangeTokenInvalid {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err == service.ErrEmailTaken {
+		httpx.Error(w, http.StatusConflict, "email_taken")
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/auth/email/confirm error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func (h *Auth) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	err := h.auth.VerifyEmail(r.Context(), req.Token)
+	if err == service.ErrVerifyTokenInvalid {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/auth/verify-email error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func (h *Auth) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	err := h.auth.ResendVerification(r.Context(), httpx.MustUserID(r))
+
+	var rlErr *service.RateLimitError
+	if errors.As(err, &rlErr) {
+		writeRetryAfter(w, http.StatusTooManyRequests, rlErr.Error(), rlErr.RetryAfter)
+		return
+	}
+	if err == service.ErrEmailAlreadyVerified {
+		httpx.Error(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/auth/verify-email/resend error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusAccepted, map[string]any{"status": "sent"})
+}
+
+// writeRetryAfter answers status with the wait in whole seconds, both as a
+// Retry-After header and in the body.
+func writeRetryAfter(w http.ResponseWriter, status int, code string, wait time.Duration) {
+	secs := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	httpx.JSON(w, status, map[string]any{
+		"error":       code,
+		"retry_after": secs,
+	})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type AdminOrders struct {
+	orders *service.OrderService
+}
+
+func NewAdminOrders(orders *service.OrderService) *AdminOrders {
+	return &AdminOrders{orders: orders}
+}
+
+type updateStatusReq struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+func (h *AdminOrders) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || orderID <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_order_id")
+		return
+	}
+
+	var req updateStatusReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+
+	o, err := h.orders.Transition(r.Context(), orderID, req.Status, userIDFromRequest(r), strings.TrimSpace(req.Reason))
+	if writeTransitionError(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/admin/orders/{id}/status error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, o)
+}
+
+func (h *AdminOrders) History(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || orderID <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_order_id")
+		return
+	}
+
+	hist, err := h.orders.History(r.Context(), orderID)
+	if err == service.ErrOrderNotFound {
+		httpx.Error(w, http.StatusNotFound, "order_not_found")
+		return
+	}
+	if err != nil {
+		log.Printf("GET /v1/admin/orders/{id}/history error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"history": hist})
+}
+
+// writeTransitionError maps the errors shared by every order status change
+// and reports whether it wrote a response.
+func writeTransitionError(w http.ResponseWriter, err error) bool {
+	var tErr *service.TransitionError
+	switch {
+	case errors.As(err, &tErr):
+		httpx.JSON(w, http.StatusConflict, map[string]any{
+			"error":     tErr.Error(),
+			"current":   tErr.Current,
+			"attempted": tErr.Attempted,
+		})
+	case err == service.ErrStatusInvalid:
+		httpx.Error(w, http.StatusBadRequest, "status_invalid")
+	case err == service.ErrOrderNotFound:
+		httpx.Error(w, http.StatusNotFound, "order_not_found")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type AdminProducts struct {
+	products *service.ProductService
+}
+
+func NewAdminProducts(products *service.ProductService) *AdminProducts {
+	return &AdminProducts{products: products}
+}
+
+type createProductReq struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	PriceCents  int64  `json:"price_cents"`
+	Currency    string `json:"currency"`
+	Stock       int32  `json:"stock"`
+}
+
+type updateProductReq struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	PriceCents  *int64  `json:"price_cents"`
+	Currency    *string `json:"currency"`
+	Stock       *int32  `json:"stock"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+func (h *AdminProducts) Create(w http.ResponseWriter, r *http.Request) {
+	var req createProductReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	p, err := h.products.Create(r.Context(), service.ProductInput{
+		Name:        req.Name,
+		Description: req.Description,
+		PriceCents:  req.PriceCents,
+		Currency:    req.Currency,
+		Stock:       req.Stock,
+	})
+	if isProductValidationErr(err) {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/admin/products error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, p)
+}
+
+func (h *AdminProducts) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_product_id")
+		return
+	}
+
+	var req updateProductReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	p, err := h.products.Update(r.Context(), id, service.ProductPatch{
+		Name:        req.Name,
+		Description: req.Description,
+		PriceCents:  req.PriceCents,
+		Currency:    req.Currency,
+		Stock:       req.Stock,
+		IsActive:    req.IsActive,
+	})
+	if isProductValidationErr(err) {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err == service.ErrProductNotFound {
+		httpx.Error(w, http.StatusNotFound, "product_not_found")
+		return
+	}
+	if err != nil {
+		log.Printf("PATCH /v1/admin/products/{id} error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, p)
+}
+
+func (h *AdminProducts) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_product_id")
+		return
+	}
+
+	err = h.products.Deactivate(r.Context(), id)
+	if err == service.ErrProductNotFound {
+		httpx.Error(w, http.StatusNotFound, "product_not_found")
+		return
+	}
+	if err != nil {
+		log.Printf("DELETE /v1/admin/products/{id} error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func isProductValidationErr(err error) bool {
+	switch err {
+	case service.ErrNameInvalid, service.ErrDescInvalid, service.ErrPriceInvalid, service.ErrStockInvalid, service.ErrCurrencyInvalid:
+		return true
+	}
+	return false
+}
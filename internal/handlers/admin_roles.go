@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type AdminRoles struct {
+	roles *service.RoleService
+}
+
+func NewAdminRoles(roles *service.RoleService) *AdminRoles {
+	return &AdminRoles{roles: roles}
+}
+
+type assignRoleReq struct {
+	Role string `json:"role"`
+}
+
+func (h *AdminRoles) List(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roles.List(r.Context())
+	if err != nil {
+		log.Printf("GET /v1/admin/roles error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"roles": roles})
+}
+
+func (h *AdminRoles) UserRoles(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+
+	roles, err := h.roles.UserRoles(r.Context(), id)
+	if err == service.ErrUserNotFound {
+		httpx.Error(w, http.StatusNotFound, "user_not_found")
+		return
+	}
+	if err != nil {
+		log.Printf("GET /v1/admin/users/{id}/roles error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"roles": roles})
+}
+
+func (h *AdminRoles) Assign(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+	var req assignRoleReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	err = h.roles.Assign(r.Context(), httpx.MustUserID(r), id, req.Role)
+	if writeRoleError(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/admin/users/{id}/roles error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func (h *AdminRoles) Remove(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+
+	err = h.roles.Remove(r.Context(), httpx.MustUserID(r), id, httpx.Param(r, "role"))
+	if err == service.ErrRoleNotHeld {
+		httpx.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if writeRoleError(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("DELETE /v1/admin/users/{id}/roles/{role} error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+// writeRoleError answers for the errors role changes share, reporting
+// whether err was one of them.
+func writeRoleError(w http.ResponseWriter, err error) bool {
+	switch err {
+	case service.ErrRoleRequired:
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+	case service.ErrUserNotFound:
+		httpx.Error(w, http.StatusNotFound, "user_not_found")
+	case service.ErrRoleNotFound:
+		httpx.Error(w, http.StatusNotFound, err.Error())
+	case service.ErrOwnRoles:
+		httpx.Error(w, http.StatusForbidden, err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type AdminUsers struct {
+	auth *service.AuthService
+}
+
+func NewAdminUsers(auth *service.AuthService) *AdminUsers {
+	return &AdminUsers{auth: auth}
+}
+
+func (h *AdminUsers) Unlock(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+
+	err = h.auth.UnlockUser(r.Context(), id)
+	if err == service.ErrUserNotFound {
+		httpx.Error(w, http.StatusNotFound, "user_not_found")
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/admin/users/{id}/unlock error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
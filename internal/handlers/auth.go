@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"log"
 	"net/http"
 	"strings"
 
@@ -11,12 +14,17 @@ import (
 )
 
 type Auth struct {
-	auth *service.AuthService
-	q    *sqlc.Queries
+	auth       *service.AuthService
+	q          *sqlc.Queries
+	carts      *service.CartService
+	cartCookie CartCookie
+	// mergeVerifiedOnly keeps guest carts from being merged into accounts
+	// whose email isn't verified, for when cart changes need one.
+	mergeVerifiedOnly bool
 }
 
-func NewAuth(auth *service.AuthService, q *sqlc.Queries) *Auth {
-	return &Auth{auth: auth, q: q}
+func NewAuth(auth *service.AuthService, q *sqlc.Queries, carts *service.CartService, cartCookie CartCookie, mergeVerifiedOnly bool) *Auth {
+	return &Auth{auth: auth, q: q, carts: carts, cartCookie: cartCookie, mergeVerifiedOnly: mergeVerifiedOnly}
 }
 
 type registerReq struct {
@@ -29,6 +37,10 @@ type loginReq struct {
 	Password string `json:"password"`
 }
 
+type refreshReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 func (h *Auth) Register(w http.ResponseWriter, r *http.Request) {
 	var req registerReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -59,7 +71,10 @@ func (h *Auth) Login(w http.ResponseWriter, r *http.Request) {
 	}
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 
-	token, err := h.auth.Login(r.Context(), req.Email, req.Password)
+	res, err := h.auth.Login(r.Context(), req.Email, req.Password, httpx.ClientIP(r))
+	if writeThrottled(w, err) {
+		return
+	}
 	if err == service.ErrInvalidCreds {
 		httpx.Error(w, http.StatusUnauthorized, "invalid_credentials")
 		return
@@ -69,10 +84,147 @@ func (h *Auth) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpx.JSON(w, http.StatusOK, map[string]any{
-		"access_token": token,
-		"token_type":   "Bearer",
-	})
+	if res.TokenPair != nil {
+		h.mergeGuestCart(w, r, res.UserID)
+	}
+	httpx.JSON(w, http.StatusOK, res)
+}
+
+// mergeGuestCart moves the cart the client shopped with as a guest into the
+// account they just signed in to. Signing in succeeds either way; a cart that
+// can't be merged stays where it was. When cart changes need a verified
+// email, so does the merge: the cart waits for a sign-in after verifying.
+func (h *Auth) mergeGuestCart(w http.ResponseWriter, r *http.Request, userID int64) {
+	token := httpx.CartToken(r)
+	if token == "" {
+		return
+	}
+	if h.mergeVerifiedOnly {
+		verified, err := h.auth.IsEmailVerified(r.Context(), userID)
+		if err != nil {
+			log.Printf("guest cart merge for user %d error: %v", userID, err)
+			return
+		}
+		if !verified {
+			return
+		}
+	}
+	err := h.carts.MergeGuestCart(r.Context(), userID, token)
+	if err == service.ErrCurrencyMismatch {
+		return
+	}
+	if err != nil {
+		log.Printf("guest cart merge for user %d error: %v", userID, err)
+		return
+	}
+	h.cartCookie.clear(w)
+}
+
+// writeThrottled answers for the login guard's lockout and rate limit
+// errors, reporting whether err was one of them.
+func writeThrottled(w http.ResponseWriter, err error) bool {
+	var lockErr *service.AccountLockedError
+	if errors.As(err, &lockErr) {
+		writeRetryAfter(w, http.StatusLocked, lockErr.Error(), lockErr.RetryAfter)
+		return true
+	}
+	var rlErr *service.RateLimitError
+	if errors.As(err, &rlErr) {
+		writeRetryAfter(w, http.StatusTooManyRequests, rlErr.Error(), rlErr.RetryAfter)
+		return true
+	}
+	return false
+}
+
+func (h *Auth) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	pair, err := h.auth.Refresh(r.Context(), req.RefreshToken)
+	if err == service.ErrRefreshTokenInvalid || err == service.ErrRefreshTokenReused {
+		httpx.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/auth/refresh error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	httpx.JSON(w, http.StatusOK, pair)
+}
+
+type forgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordReq struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (h *Auth) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req forgotPasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	h.auth.ForgotPassword(r.Context(), req.Email)
+	httpx.JSON(w, http.StatusAccepted, map[string]any{"status": "ok"})
+}
+
+func (h *Auth) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	err := h.auth.ResetPassword(r.Context(), req.Token, req.Password)
+	if err == service.ErrPasswordTooShort || err == service.ErrResetTokenInvalid {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/auth/password/reset error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+type logoutReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (h *Auth) Logout(w http.ResponseWriter, r *http.Request) {
+	// the body is optional
+	var req logoutReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	tok, _ := httpx.Token(r)
+	if err := h.auth.Logout(r.Context(), httpx.MustUserID(r), tok.ID, tok.ExpiresAt, req.RefreshToken); err != nil {
+		log.Printf("POST /v1/auth/logout error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func (h *Auth) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	if err := h.auth.LogoutAll(r.Context(), httpx.MustUserID(r)); err != nil {
+		log.Printf("POST /v1/auth/logout-all error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
 }
 
 func (h *Auth) Me(w http.ResponseWriter, r *http.Request) {
@@ -82,10 +234,17 @@ func (h *Auth) Me(w http.ResponseWriter, r *http.Request) {
 		httpx.Error(w, http.StatusInternalServerError, "server_error")
 		return
 	}
+	roles, err := h.q.ListUserRoles(r.Context(), userID)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
 
 	httpx.JSON(w, http.StatusOK, map[string]any{
-		"id":    u.ID,
-		"email": u.Email,
-		"role":  u.Role,
+		"id":             u.ID,
+		"email":          u.Email,
+		"email_verified": u.EmailVerifiedAt.Valid,
+		"roles":          append([]string{}, roles...),
+		"permissions":    append([]string{}, httpx.Permissions(r)...),
 	})
 }
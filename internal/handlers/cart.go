@@ -2,26 +2,72 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/angelchiav/go-ecommerce/internal/httpx"
 	"github.com/angelchiav/go-ecommerce/internal/service"
 )
 
 type Cart struct {
-	cart *service.CartService
+	cart   *service.CartService
+	cookie CartCookie
 }
 
-func NewCart(cart *service.CartService) *Cart { return &Cart{cart: cart} }
+func NewCart(cart *service.CartService, cookie CartCookie) *Cart {
+	return &Cart{cart: cart, cookie: cookie}
+}
+
+// CartCookie configures the cookie a guest's cart token is stored in.
+type CartCookie struct {
+	TTL    time.Duration
+	Secure bool
+}
+
+// set hands a new guest cart token to the client, both as a cookie for
+// browsers and in the X-Cart-Token header for other clients.
+func (c CartCookie) set(w http.ResponseWriter, token string) {
+	w.Header().Set(httpx.CartTokenHeader, token)
+	http.SetCookie(w, &http.Cookie{
+		Name:     httpx.CartTokenCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(c.TTL.Seconds()),
+		HttpOnly: true,
+		Secure:   c.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (c CartCookie) clear(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     httpx.CartTokenCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
 
 func userIDFromRequest(r *http.Request) int64 {
 	return httpx.MustUserID(r)
 }
 
+// cartOwner picks the signed-in user's cart, or the guest cart named by the
+// request's cart token.
+func cartOwner(r *http.Request) service.CartOwner {
+	if uid, ok := httpx.UserID(r); ok {
+		return service.UserCart(uid)
+	}
+	return service.GuestCart(httpx.CartToken(r))
+}
+
 func (h *Cart) Get(w http.ResponseWriter, r *http.Request) {
-	cv, err := h.cart.Get(r.Context(), userIDFromRequest(r))
+	cv, err := h.cart.Get(r.Context(), cartOwner(r))
 	if err != nil {
 		log.Printf("GET /v1/cart error: %v", err)
 		httpx.Error(w, http.StatusInternalServerError, "server_error")
@@ -46,17 +92,32 @@ func (h *Cart) AddItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.cart.AddItem(r.Context(), userIDFromRequest(r), req.ProductID, req.Qty)
+	token, err := h.cart.AddItem(r.Context(), cartOwner(r), req.ProductID, req.Qty)
 	if err == service.ErrQtyInvalid {
 		httpx.Error(w, http.StatusBadRequest, "qty_invalid")
 		return
 	}
+	if err == service.ErrProductNotFound {
+		httpx.Error(w, http.StatusNotFound, "product_not_found")
+		return
+	}
+	if err == service.ErrCurrencyMismatch {
+		httpx.Error(w, http.StatusConflict, "currency_mismatch")
+		return
+	}
+	if writeStockError(w, err) {
+		return
+	}
 	if err != nil {
 		log.Printf("POST /v1/cart/items error: %v", err)
 		httpx.Error(w, http.StatusInternalServerError, "server_error")
 		return
 	}
 
+	if token != "" {
+		// never in the body, which may be stored for idempotent replays
+		h.cookie.set(w, token)
+	}
 	httpx.JSON(w, http.StatusCreated, map[string]any{"status": "ok"})
 }
 
@@ -83,11 +144,22 @@ func (h *Cart) UpdateItemQty(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.cart.UpdateItemQty(r.Context(), userIDFromRequest(r), itemID, req.Qty)
+	err = h.cart.UpdateItemQty(r.Context(), cartOwner(r), itemID, req.Qty)
 	if err == service.ErrQtyInvalid {
 		httpx.Error(w, http.StatusBadRequest, "qty_invalid")
 		return
 	}
+	if err == service.ErrItemNotFound {
+		httpx.Error(w, http.StatusNotFound, "item_not_found")
+		return
+	}
+	if err == service.ErrProductNotFound {
+		httpx.Error(w, http.StatusNotFound, "product_not_found")
+		return
+	}
+	if writeStockError(w, err) {
+		return
+	}
 
 	if err != nil {
 		log.Printf("PATCH /v1/cart/items/{id} error: %v", err)
@@ -106,10 +178,31 @@ func (h *Cart) DeleteItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.cart.DeleteItem(r.Context(), userIDFromRequest(r), itemID); err != nil {
+	err = h.cart.DeleteItem(r.Context(), cartOwner(r), itemID)
+	if err == service.ErrItemNotFound {
+		httpx.Error(w, http.StatusNotFound, "item_not_found")
+		return
+	}
+	if err != nil {
 		log.Printf("DELETE /v1/cart/items/{id} error: %v", err)
 		httpx.Error(w, http.StatusInternalServerError, "server_error")
 		return
 	}
 	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
 }
+
+// writeStockError reports whether err was a reservation shortfall and, if so,
+// writes the 409 describing it.
+func writeStockError(w http.ResponseWriter, err error) bool {
+	var stockErr *service.StockError
+	if !errors.As(err, &stockErr) {
+		return false
+	}
+	httpx.JSON(w, http.StatusConflict, map[string]any{
+		"error":      stockErr.Error(),
+		"product_id": stockErr.ProductID,
+		"requested":  stockErr.Requested,
+		"available":  stockErr.Available,
+	})
+	return true
+}
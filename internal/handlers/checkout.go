@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type Checkout struct {
+	checkout *service.CheckoutService
+}
+
+func NewCheckout(checkout *service.CheckoutService) *Checkout {
+	return &Checkout{checkout: checkout}
+}
+
+type checkoutAddressReq struct {
+	FullName   string `json:"full_name"`
+	Company    string `json:"company"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone"`
+}
+
+type checkoutReq struct {
+	ShippingAddressID int64               `json:"shipping_address_id"`
+	ShippingAddress   *checkoutAddressReq `json:"shipping_address"`
+	BillingAddressID  int64               `json:"billing_address_id"`
+	BillingAddress    *checkoutAddressReq `json:"billing_address"`
+}
+
+func (req *checkoutAddressReq) postal() *service.PostalAddress {
+	if req == nil {
+		return nil
+	}
+	a := service.PostalAddress(*req)
+	return &a
+}
+
+// Checkout places the order. The body is optional; without it the user's
+// default addresses are used.
+func (h *Checkout) Checkout(w http.ResponseWriter, r *http.Request) {
+	var req checkoutReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	order, err := h.checkout.Checkout(r.Context(), userIDFromRequest(r), service.CheckoutInput{
+		ShippingAddressID: req.ShippingAddressID,
+		ShippingAddress:   req.ShippingAddress.postal(),
+		BillingAddressID:  req.BillingAddressID,
+		BillingAddress:    req.BillingAddress.postal(),
+	})
+
+	var coErr *service.CheckoutError
+	if errors.As(err, &coErr) {
+		httpx.JSON(w, http.StatusConflict, map[string]any{
+			"error": coErr.Error(),
+			"lines": coErr.Lines,
+		})
+		return
+	}
+	var addrErr *service.CheckoutAddressError
+	if errors.As(err, &addrErr) {
+		httpx.JSON(w, http.StatusBadRequest, map[string]any{
+			"error":   addrErr.Error(),
+			"address": addrErr.Address,
+		})
+		return
+	}
+	if err == service.ErrShippingAddressRequired {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err == service.ErrCartEmpty {
+		httpx.Error(w, http.StatusBadRequest, "cart_empty")
+		return
+	}
+	if err == service.ErrCurrencyMismatch {
+		httpx.Error(w, http.StatusConflict, "currency_mismatch")
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/checkout error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	httpx.JSON(w, http.StatusCreated, order)
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/jwtkeys"
+)
+
+type JWKS struct {
+	keys *jwtkeys.KeySet
+}
+
+func NewJWKS(keys *jwtkeys.KeySet) *JWKS { return &JWKS{keys: keys} }
+
+func (h *JWKS) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	httpx.JSON(w, http.StatusOK, h.keys.JWKS())
+}
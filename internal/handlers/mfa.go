@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type verifyMFAReq struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type mfaCodeReq struct {
+	Code string `json:"code"`
+}
+
+type passwordReq struct {
+	Password string `json:"password"`
+}
+
+type recoveryCodesResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h *Auth) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req verifyMFAReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	pair, err := h.auth.VerifyMFA(r.Context(), req.MFAToken, req.Code, httpx.ClientIP(r))
+	if writeThrottled(w, err) {
+		return
+	}
+	if err == service.ErrMFATokenInvalid || err == service.ErrMFACodeInvalid {
+		httpx.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/auth/mfa/verify error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	h.mergeGuestCart(w, r, pair.UserID)
+	httpx.JSON(w, http.StatusOK, pair)
+}
+
+func (h *Auth) EnrolTOTP(w http.ResponseWriter, r *http.Request) {
+	enrolment, err := h.auth.EnrolTOTP(r.Context(), httpx.MustUserID(r))
+	if err == service.ErrMFAAlreadyEnabled {
+		httpx.Error(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/me/mfa/totp error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, enrolment)
+}
+
+func (h *Auth) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var req mfaCodeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	res, err := h.auth.ConfirmTOTP(r.Context(), httpx.MustUserID(r), req.Code)
+	if err == service.ErrMFACodeInvalid {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err == service.ErrMFANotPending || err == service.ErrMFAAlreadyEnabled {
+		httpx.Error(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/me/mfa/totp/confirm error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, res)
+}
+
+func (h *Auth) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	var req passwordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	err := h.auth.DisableTOTP(r.Context(), httpx.MustUserID(r), req.Password)
+	if err == service.ErrWrongPassword {
+		httpx.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err == service.ErrMFANotEnabled {
+		httpx.Error(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("DELETE /v1/me/mfa/totp error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func (h *Auth) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req passwordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	codes, err := h.auth.RegenerateRecoveryCodes(r.Context(), httpx.MustUserID(r), req.Password)
+	if err == service.ErrWrongPassword {
+		httpx.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err == service.ErrMFANotEnabled {
+		httpx.Error(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/me/mfa/recovery-codes error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, recoveryCodesResp{RecoveryCodes: codes})
+}
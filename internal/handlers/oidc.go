@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/oidc"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type OIDC struct {
+	login *service.OIDCLogin
+}
+
+func NewOIDC(login *service.OIDCLogin) *OIDC { return &OIDC{login: login} }
+
+// Start redirects the browser to the provider's sign-in page.
+func (h *OIDC) Start(w http.ResponseWriter, r *http.Request) {
+	u, err := h.login.Start(r.Context(), httpx.Param(r, "provider"))
+	if err == service.ErrUnknownProvider {
+		httpx.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("GET /v1/auth/oidc/{provider} error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, u, http.StatusFound)
+}
+
+// Callback is where the provider sends the browser back to.
+func (h *OIDC) Callback(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	if qs.Get("error") != "" {
+		httpx.Error(w, http.StatusUnauthorized, "oidc_login_denied")
+		return
+	}
+
+	res, err := h.login.Finish(r.Context(), httpx.Param(r, "provider"), qs.Get("state"), qs.Get("code"))
+	if err == service.ErrUnknownProvider {
+		httpx.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err == service.ErrOIDCStateInvalid {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err == service.ErrOIDCEmailUnverified {
+		httpx.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, oidc.ErrExchange) || errors.Is(err, oidc.ErrIDTokenInvalid) {
+		log.Printf("GET /v1/auth/oidc/{provider}/callback error: %v", err)
+		httpx.Error(w, http.StatusUnauthorized, "oidc_login_failed")
+		return
+	}
+	if err != nil {
+		log.Printf("GET /v1/auth/oidc/{provider}/callback error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	httpx.JSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type Orders struct {
+	orders *service.OrderService
+}
+
+func NewOrders(orders *service.OrderService) *Orders { return &Orders{orders: orders} }
+
+func (h *Orders) List(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	f := service.OrderFilter{
+		Status: qs.Get("status"),
+		Cursor: qs.Get("cursor"),
+	}
+	if v := qs.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httpx.Error(w, http.StatusBadRequest, "invalid_limit")
+			return
+		}
+		f.Limit = n
+	}
+
+	page, err := h.orders.List(r.Context(), userIDFromRequest(r), f)
+	if err == service.ErrInvalidCursor {
+		httpx.Error(w, http.StatusBadRequest, "invalid_cursor")
+		return
+	}
+	if err == service.ErrStatusInvalid {
+		httpx.Error(w, http.StatusBadRequest, "status_invalid")
+		return
+	}
+	if err != nil {
+		log.Printf("GET /v1/orders error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, page)
+}
+
+func (h *Orders) Get(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || orderID <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_order_id")
+		return
+	}
+
+	o, err := h.orders.Get(r.Context(), userIDFromRequest(r), orderID)
+	if err == service.ErrOrderNotFound {
+		httpx.Error(w, http.StatusNotFound, "order_not_found")
+		return
+	}
+	if err != nil {
+		log.Printf("GET /v1/orders/{id} error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, o)
+}
+
+type cancelOrderReq struct {
+	Reason string `json:"reason"`
+}
+
+func (h *Orders) Cancel(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || orderID <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_order_id")
+		return
+	}
+
+	var req cancelOrderReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	o, err := h.orders.Cancel(r.Context(), userIDFromRequest(r), orderID, strings.TrimSpace(req.Reason))
+	if writeTransitionError(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/orders/{id}/cancel error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, o)
+}
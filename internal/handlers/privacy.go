@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type Privacy struct {
+	auth   *service.AuthService
+	export *service.DataExportService
+}
+
+func NewPrivacy(auth *service.AuthService, export *service.DataExportService) *Privacy {
+	return &Privacy{auth: auth, export: export}
+}
+
+type deleteAccountReq struct {
+	Password string `json:"password"`
+}
+
+// Export sends the user everything stored about them as a JSON download.
+func (h *Privacy) Export(w http.ResponseWriter, r *http.Request) {
+	userID := httpx.MustUserID(r)
+
+	data, err := h.export.Export(r.Context(), userID)
+	if err != nil {
+		log.Printf("GET /v1/me/export error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="account-%d-%s.json"`,
+		userID, data.ExportedAt.Format("20060102")))
+	httpx.JSON(w, http.StatusOK, data)
+}
+
+// DeleteAccount schedules the account for deletion. The body is only needed
+// for accounts with a password.
+func (h *Privacy) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	var req deleteAccountReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	deleteAfter, err := h.auth.RequestDeletion(r.Context(), httpx.MustUserID(r), req.Password)
+	if err == service.ErrWrongPassword {
+		httpx.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("DELETE /v1/me error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusAccepted, map[string]any{
+		"status":       "deletion_scheduled",
+		"delete_after": deleteAfter.UTC().Format(time.RFC3339),
+	})
+}
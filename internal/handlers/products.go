@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type Products struct {
+	products *service.ProductService
+}
+
+func NewProducts(products *service.ProductService) *Products {
+	return &Products{products: products}
+}
+
+func (h *Products) List(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	f := service.ProductFilter{
+		Query:    qs.Get("q"),
+		Currency: qs.Get("currency"),
+		Sort:     qs.Get("sort"),
+		Cursor:   qs.Get("cursor"),
+	}
+	if v := qs.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httpx.Error(w, http.StatusBadRequest, "invalid_limit")
+			return
+		}
+		f.Limit = n
+	}
+
+	var err error
+	if f.MinPriceCents, err = queryCents(qs, "min_price_cents"); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_min_price_cents")
+		return
+	}
+	if f.MaxPriceCents, err = queryCents(qs, "max_price_cents"); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_max_price_cents")
+		return
+	}
+
+	page, err := h.products.List(r.Context(), f)
+	if err == service.ErrInvalidCursor || err == service.ErrSortInvalid || err == service.ErrPriceRange ||
+		err == service.ErrCurrencyInvalid || err == service.ErrCurrencyRequired {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("GET /v1/products error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, page)
+}
+
+func (h *Products) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_product_id")
+		return
+	}
+
+	p, err := h.products.Get(r.Context(), id)
+	if err == service.ErrProductNotFound {
+		httpx.Error(w, http.StatusNotFound, "product_not_found")
+		return
+	}
+	if err != nil {
+		log.Printf("GET /v1/products/{id} error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, p)
+}
+
+// queryCents parses an optional non-negative amount in cents from the query
+// string; a missing key yields nil.
+func queryCents(qs url.Values, key string) (*int64, error) {
+	v := qs.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return nil, errors.New("invalid_cents")
+	}
+	return &n, nil
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/service"
+)
+
+type Profile struct {
+	profiles  *service.ProfileService
+	addresses *service.AddressService
+}
+
+func NewProfile(profiles *service.ProfileService, addresses *service.AddressService) *Profile {
+	return &Profile{profiles: profiles, addresses: addresses}
+}
+
+type updateProfileReq struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
+}
+
+type addressReq struct {
+	FullName          string `json:"full_name"`
+	Company           string `json:"company"`
+	Line1             string `json:"line1"`
+	Line2             string `json:"line2"`
+	City              string `json:"city"`
+	Region            string `json:"region"`
+	PostalCode        string `json:"postal_code"`
+	Country           string `json:"country"`
+	Phone             string `json:"phone"`
+	IsDefaultShipping bool   `json:"is_default_shipping"`
+	IsDefaultBilling  bool   `json:"is_default_billing"`
+}
+
+func (req addressReq) input() service.AddressInput {
+	return service.AddressInput{
+		PostalAddress: service.PostalAddress{
+			FullName:   req.FullName,
+			Company:    req.Company,
+			Line1:      req.Line1,
+			Line2:      req.Line2,
+			City:       req.City,
+			Region:     req.Region,
+			PostalCode: req.PostalCode,
+			Country:    req.Country,
+			Phone:      req.Phone,
+		},
+		DefaultShipping: req.IsDefaultShipping,
+		DefaultBilling:  req.IsDefaultBilling,
+	}
+}
+
+func (h *Profile) Get(w http.ResponseWriter, r *http.Request) {
+	p, err := h.profiles.Get(r.Context(), httpx.MustUserID(r))
+	if err != nil {
+		log.Printf("GET /v1/me/profile error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, p)
+}
+
+func (h *Profile) Update(w http.ResponseWriter, r *http.Request) {
+	var req updateProfileReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	p, err := h.profiles.Update(r.Context(), httpx.MustUserID(r), service.ProfileInput{
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Phone:     req.Phone,
+	})
+	if err == service.ErrFirstNameInvalid || err == service.ErrLastNameInvalid || err == service.ErrPhoneInvalid {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("PUT /v1/me/profile error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, p)
+}
+
+func (h *Profile) ListAddresses(w http.ResponseWriter, r *http.Request) {
+	addrs, err := h.addresses.List(r.Context(), httpx.MustUserID(r))
+	if err != nil {
+		log.Printf("GET /v1/me/addresses error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"addresses": addrs})
+}
+
+func (h *Profile) GetAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_address_id")
+		return
+	}
+
+	a, err := h.addresses.Get(r.Context(), httpx.MustUserID(r), id)
+	if err == service.ErrAddressNotFound {
+		httpx.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("GET /v1/me/addresses/{id} error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, a)
+}
+
+func (h *Profile) CreateAddress(w http.ResponseWriter, r *http.Request) {
+	var req addressReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	a, err := h.addresses.Create(r.Context(), httpx.MustUserID(r), req.input())
+	if isAddressValidationErr(err) {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err == service.ErrAddressLimit {
+		httpx.Error(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("POST /v1/me/addresses error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusCreated, a)
+}
+
+func (h *Profile) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_address_id")
+		return
+	}
+	var req addressReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+
+	a, err := h.addresses.Update(r.Context(), httpx.MustUserID(r), id, req.input())
+	if isAddressValidationErr(err) {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err == service.ErrAddressNotFound {
+		httpx.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("PUT /v1/me/addresses/{id} error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, a)
+}
+
+func (h *Profile) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(httpx.Param(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.Error(w, http.StatusBadRequest, "invalid_address_id")
+		return
+	}
+
+	err = h.addresses.Delete(r.Context(), httpx.MustUserID(r), id)
+	if err == service.ErrAddressNotFound {
+		httpx.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("DELETE /v1/me/addresses/{id} error: %v", err)
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	httpx.JSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func isAddressValidationErr(err error) bool {
+	switch err {
+	case service.ErrFullNameInvalid, service.ErrCompanyInvalid, service.ErrLine1Invalid, service.ErrLine2Invalid,
+		service.ErrCityInvalid, service.ErrRegionInvalid, service.ErrPostalCodeInvalid, service.ErrCountryUnsupported,
+		service.ErrPhoneInvalid:
+		return true
+	}
+	return false
+}
@@ -3,19 +3,32 @@ package httpx
 import (
 	"context"
 	"net/http"
+	"slices"
+	"time"
 )
 
 type CtxKey string
 
 const userIDKey CtxKey = "user_id"
-const userRoleKey CtxKey = "user_role"
+const permissionsKey CtxKey = "permissions"
+const tokenKey CtxKey = "token"
 
-func WithAuth(ctx context.Context, userID int64, role string) context.Context {
+// TokenInfo identifies the access token a request was authenticated with.
+type TokenInfo struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+func WithAuth(ctx context.Context, userID int64, permissions []string) context.Context {
 	ctx = context.WithValue(ctx, userIDKey, userID)
-	ctx = context.WithValue(ctx, userRoleKey, role)
+	ctx = context.WithValue(ctx, permissionsKey, permissions)
 	return ctx
 }
 
+func WithToken(ctx context.Context, t TokenInfo) context.Context {
+	return context.WithValue(ctx, tokenKey, t)
+}
+
 func UserID(r *http.Request) (int64, bool) {
 	v := r.Context().Value(userIDKey)
 	id, ok := v.(int64)
@@ -29,3 +42,20 @@ func MustUserID(r *http.Request) int64 {
 	}
 	return id
 }
+
+// Permissions lists what the authenticated user may do, as of when their
+// access token was issued.
+func Permissions(r *http.Request) []string {
+	perms, _ := r.Context().Value(permissionsKey).([]string)
+	return perms
+}
+
+func HasPermission(r *http.Request, perm string) bool {
+	return slices.Contains(Permissions(r), perm)
+}
+
+func Token(r *http.Request) (TokenInfo, bool) {
+	v := r.Context().Value(tokenKey)
+	t, ok := v.(TokenInfo)
+	return t, ok
+}
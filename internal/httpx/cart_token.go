@@ -0,0 +1,23 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	CartTokenHeader = "X-Cart-Token"
+	CartTokenCookie = "cart_token"
+)
+
+// CartToken returns the guest cart token sent with the request, from the
+// X-Cart-Token header or, failing that, the cart_token cookie.
+func CartToken(r *http.Request) string {
+	if t := strings.TrimSpace(r.Header.Get(CartTokenHeader)); t != "" {
+		return t
+	}
+	if c, err := r.Cookie(CartTokenCookie); err == nil {
+		return c.Value
+	}
+	return ""
+}
@@ -2,7 +2,9 @@ package httpx
 
 import (
 	"log"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -25,3 +27,35 @@ func Logger(next http.Handler) http.Handler {
 		log.Printf("%s %s %s", r.Method, r.URL.Path, time.Since(start))
 	})
 }
+
+// RealIP takes the client address from header, set by a reverse proxy in
+// front of the API, instead of the connection's peer. With an empty header it
+// does nothing. Only enable it behind a proxy that overwrites the header,
+// otherwise clients can choose their own address.
+func RealIP(header string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if header == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// with X-Forwarded-For the last hop is the one our proxy added
+			v := r.Header.Get(header)
+			if i := strings.LastIndex(v, ","); i >= 0 {
+				v = v[i+1:]
+			}
+			if ip := net.ParseIP(strings.TrimSpace(v)); ip != nil {
+				r.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP is the address of the client, without the port.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,219 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+
+	maxIdempotencyKeyLen = 255
+	maxIdempotentBody    = 1 << 20
+)
+
+// IdempotencyRecord is what a store holds for a key. StatusCode is zero while
+// the first request carrying the key is still running.
+type IdempotencyRecord struct {
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	// Header holds those of replayedHeaders the response set.
+	Header http.Header
+	Body   []byte
+}
+
+// replayedHeaders are kept with a response, besides its Content-Type, and
+// sent again with every replay: a retried first add-to-cart must still hand
+// the guest its cart token.
+var replayedHeaders = []string{CartTokenHeader, "Set-Cookie"}
+
+type IdempotencyStore interface {
+	// Begin claims key within scope for a request with the given hash. If the
+	// key is already taken it returns the existing record and false.
+	Begin(ctx context.Context, scope, key, requestHash string) (*IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, scope, key string, rec IdempotencyRecord) error
+	// Release forgets a claimed key so the request can be retried.
+	Release(ctx context.Context, scope, key string) error
+}
+
+// Idempotency makes POST/PATCH/DELETE requests carrying an Idempotency-Key
+// header safe to retry: the first response is stored and replayed for later
+// requests with the same key, and reusing a key for a different request is
+// rejected. Keys are scoped per user, or per cart for guests, so it must run
+// after AuthJWT or OptionalAuthJWT. Guests without a cart yet are scoped by
+// client address. Server errors are not stored, leaving the client free to retry them.
+func Idempotency(store IdempotencyStore) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyHeader)
+			if key == "" || !isMutating(r.Method) {
+				next(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				Error(w, http.StatusBadRequest, "invalid_idempotency_key")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil {
+				Error(w, http.StatusBadRequest, "invalid_body")
+				return
+			}
+			if len(body) > maxIdempotentBody {
+				Error(w, http.StatusRequestEntityTooLarge, "body_too_large")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			scope := idempotencyScope(r)
+			hash := requestHash(r, body)
+
+			prev, claimed, err := store.Begin(ctx, scope, key, hash)
+			if err != nil {
+				log.Printf("idempotency begin error: %v", err)
+				Error(w, http.StatusInternalServerError, "server_error")
+				return
+			}
+			if !claimed {
+				replay(w, prev, hash)
+				return
+			}
+
+			done := false
+			defer func() {
+				// covers panics and 5xx responses alike
+				if !done {
+					if err := store.Release(context.WithoutCancel(ctx), scope, key); err != nil {
+						log.Printf("idempotency release error: %v", err)
+					}
+				}
+			}()
+
+			rw := &recordingWriter{ResponseWriter: w}
+			next(rw, r)
+
+			if rw.status >= http.StatusInternalServerError {
+				return
+			}
+			err = store.Complete(context.WithoutCancel(ctx), scope, key, IdempotencyRecord{
+				RequestHash: hash,
+				StatusCode:  rw.statusCode(),
+				ContentType: rw.Header().Get("Content-Type"),
+				Header:      keptHeaders(rw.Header()),
+				Body:        rw.body.Bytes(),
+			})
+			if err != nil {
+				log.Printf("idempotency complete error: %v", err)
+				return
+			}
+			done = true
+		}
+	}
+}
+
+func replay(w http.ResponseWriter, rec *IdempotencyRecord, hash string) {
+	if rec.RequestHash != hash {
+		Error(w, http.StatusUnprocessableEntity, "idempotency_key_reused")
+		return
+	}
+	if rec.StatusCode == 0 {
+		Error(w, http.StatusConflict, "idempotency_request_in_progress")
+		return
+	}
+	if rec.ContentType != "" {
+		w.Header().Set("Content-Type", rec.ContentType)
+	}
+	for name, values := range rec.Header {
+		for _, v := range values {
+			w.Header().Add(name, v)
+		}
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(rec.StatusCode)
+	_, _ = w.Write(rec.Body)
+}
+
+func isMutating(method string) bool {
+	return method == http.MethodPost || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// idempotencyScope names whose keys a request's key is among. A guest's
+// first request has no cart token yet, nor does its retry, since the token
+// is in the response that was lost; such requests are scoped by client
+// address, so only clients behind the same address share their keys.
+func idempotencyScope(r *http.Request) string {
+	if uid, ok := UserID(r); ok {
+		return "user:" + strconv.FormatInt(uid, 10)
+	}
+	if t := CartToken(r); t != "" {
+		return "cart:" + sha256Hex(t)
+	}
+	return "ip:" + sha256Hex(ClientIP(r))
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// keptHeaders picks replayedHeaders out of h.
+func keptHeaders(h http.Header) http.Header {
+	var kept http.Header
+	for _, name := range replayedHeaders {
+		if values := h.Values(name); len(values) > 0 {
+			if kept == nil {
+				kept = http.Header{}
+			}
+			kept[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return kept
+}
+
+// requestHash identifies a request by method, path and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method)
+	h.Write([]byte{0})
+	io.WriteString(h, r.URL.Path)
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
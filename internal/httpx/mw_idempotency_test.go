@@ -0,0 +1,90 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// memoryIdempotencyStore is an IdempotencyStore in a map.
+type memoryIdempotencyStore map[string]*IdempotencyRecord
+
+func (s memoryIdempotencyStore) Begin(_ context.Context, scope, key, requestHash string) (*IdempotencyRecord, bool, error) {
+	if rec, ok := s[scope+" "+key]; ok {
+		return rec, false, nil
+	}
+	s[scope+" "+key] = &IdempotencyRecord{RequestHash: requestHash}
+	return nil, true, nil
+}
+
+func (s memoryIdempotencyStore) Complete(_ context.Context, scope, key string, rec IdempotencyRecord) error {
+	s[scope+" "+key] = &rec
+	return nil
+}
+
+func (s memoryIdempotencyStore) Release(_ context.Context, scope, key string) error {
+	delete(s, scope+" "+key)
+	return nil
+}
+
+func TestIdempotencyGuestFirstAdd(t *testing.T) {
+	calls := 0
+	h := Idempotency(memoryIdempotencyStore{})(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		token := "token-" + strings.Repeat("x", calls)
+		w.Header().Set(CartTokenHeader, token)
+		http.SetCookie(w, &http.Cookie{Name: CartTokenCookie, Value: token})
+		JSON(w, http.StatusCreated, map[string]string{"status": "ok"})
+	})
+
+	add := func(addr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/v1/cart/items", strings.NewReader(`{"product_id":1,"qty":1}`))
+		r.RemoteAddr = addr
+		r.Header.Set(IdempotencyHeader, "first-add")
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w
+	}
+
+	first := add("203.0.113.7:5000")
+	retry := add("203.0.113.7:5001")
+	if calls != 1 {
+		t.Fatalf("handler ran %d times for a retry, want 1", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry = %d %v, want a replayed 201", retry.Code, retry.Header())
+	}
+	for _, name := range []string{CartTokenHeader, "Set-Cookie", "Content-Type"} {
+		if got, want := retry.Header().Get(name), first.Header().Get(name); got != want {
+			t.Errorf("replayed %s = %q, want %q", name, got, want)
+		}
+	}
+
+	// the same key from another address is another client's
+	if other := add("198.51.100.2:5000"); other.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("a request from another address got the first client's response")
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	guest := httptest.NewRequest("POST", "/", nil)
+	guest.RemoteAddr = "203.0.113.7:5000"
+	cart := guest.Clone(guest.Context())
+	cart.Header.Set(CartTokenHeader, "abc")
+	user := cart.WithContext(WithAuth(cart.Context(), 7, nil))
+
+	if got := idempotencyScope(user); got != "user:7" {
+		t.Errorf("scope with a user = %q, want user:7", got)
+	}
+	if got := idempotencyScope(cart); !strings.HasPrefix(got, "cart:") || strings.Contains(got, "abc") {
+		t.Errorf("scope with a cart token = %q, want the token's hash", got)
+	}
+	if got := idempotencyScope(guest); !strings.HasPrefix(got, "ip:") || strings.Contains(got, "203.0.113.7") {
+		t.Errorf("scope for a new guest = %q, want the address's hash", got)
+	}
+}
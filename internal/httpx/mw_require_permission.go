@@ -0,0 +1,22 @@
+package httpx
+
+import (
+	"net/http"
+)
+
+// RequirePermission only lets requests through whose authenticated user
+// holds every one of perms. It must run after AuthJWT, which puts the
+// permissions from the access token in the context.
+func RequirePermission(perms ...string) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			for _, p := range perms {
+				if !HasPermission(r, p) {
+					Error(w, http.StatusForbidden, "forbidden")
+					return
+				}
+			}
+			next(w, r)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package jwtkeys
+
+import "github.com/golang-jwt/jwt/v5"
+
+// Claims are the claims carried by an access token. Version is the user's
+// token version when the token was issued; bumping it revokes every earlier
+// token.
+type Claims struct {
+	Permissions []string `json:"perms,omitempty"`
+	Version     int32    `json:"ver"`
+	jwt.RegisteredClaims
+}
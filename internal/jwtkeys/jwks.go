@@ -0,0 +1,112 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// JWK is the public half of a key as published in a JWKS (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public keys of the set, verify-only ones included so
+// tokens signed before a rotation keep verifying elsewhere. Shared secrets
+// are never published.
+func (ks *KeySet) JWKS() JWKS {
+	out := JWKS{Keys: []JWK{}}
+	for _, k := range ks.sortedKeys() {
+		b64 := base64.RawURLEncoding.EncodeToString
+		switch pub := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			out.Keys = append(out.Keys, JWK{
+				Kty: "RSA",
+				Kid: k.ID,
+				Use: "sig",
+				Alg: k.Method.Alg(),
+				N:   b64(pub.N.Bytes()),
+				E:   b64(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			out.Keys = append(out.Keys, JWK{
+				Kty: "OKP",
+				Kid: k.ID,
+				Use: "sig",
+				Alg: k.Method.Alg(),
+				Crv: "Ed25519",
+				X:   b64(pub),
+			})
+		}
+	}
+	return out
+}
+
+// FromJWKS builds a verify-only key set from another issuer's published
+// keys, for checking tokens it signed. Keys of unsupported types, or meant
+// for encryption, are skipped.
+func FromJWKS(set JWKS) (*KeySet, error) {
+	ks := newKeySet()
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		pub, err := jwk.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		if pub == nil {
+			continue
+		}
+		k, err := newKey(jwk.Kid, pub)
+		if err != nil {
+			return nil, err
+		}
+		if jwk.Alg != "" && jwk.Alg != k.Method.Alg() {
+			continue
+		}
+		ks.keys[k.ID] = k
+	}
+	return ks, nil
+}
+
+// publicKey decodes the key material, or returns nil for key types this
+// package doesn't use.
+func (j JWK) publicKey() (any, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+	switch {
+	case j.Kty == "RSA":
+		n, err := b64(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: %s: bad modulus: %w", j.Kid, err)
+		}
+		e, err := b64(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwtkeys: %s: bad exponent", j.Kid)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case j.Kty == "OKP" && j.Crv == "Ed25519":
+		x, err := b64(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwtkeys: %s: bad Ed25519 key", j.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
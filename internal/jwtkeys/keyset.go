@@ -0,0 +1,162 @@
+// Package jwtkeys holds the keys access tokens are signed and verified with.
+//
+// A key set has one signing key and any number of verify-only keys, each
+// identified by a kid that is written into the token header. Rotating keys
+// means adding a new key, making it the signing key, and removing the old one
+// once every token it signed has expired.
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnknownKey = errors.New("jwtkeys: unknown kid")
+
+const minRSABits = 2048
+
+// Key is a single signing or verification key. signKey is nil for keys that
+// only verify.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+func (k *Key) CanSign() bool { return k.signKey != nil }
+
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+func newKeySet() *KeySet {
+	return &KeySet{keys: map[string]*Key{}}
+}
+
+// NewHMAC returns a key set that signs and verifies with a shared secret and
+// no kid, the way tokens were issued before asymmetric keys.
+func NewHMAC(secret []byte) *KeySet {
+	ks := newKeySet()
+	k := hmacKey(secret)
+	ks.keys[k.ID] = k
+	ks.signing = k
+	return ks
+}
+
+// NewSigning returns a key set holding just the given private key, for
+// signing tokens as kid.
+func NewSigning(kid string, key any) (*KeySet, error) {
+	k, err := newKey(kid, key)
+	if err != nil {
+		return nil, err
+	}
+	if !k.CanSign() {
+		return nil, fmt.Errorf("jwtkeys: %s: not a private key", kid)
+	}
+	ks := newKeySet()
+	ks.keys[kid] = k
+	ks.signing = k
+	return ks, nil
+}
+
+func hmacKey(secret []byte) *Key {
+	return &Key{
+		ID:        "",
+		Method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+func newKey(kid string, key any) (*Key, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("jwtkeys: %s: RSA key shorter than %d bits", kid, minRSABits)
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("jwtkeys: %s: RSA key shorter than %d bits", kid, minRSABits)
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, verifyKey: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, signKey: k, verifyKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, verifyKey: k}, nil
+	}
+	return nil, fmt.Errorf("jwtkeys: %s: unsupported key type %T", kid, key)
+}
+
+// SigningKey is the key new tokens are signed with.
+func (ks *KeySet) SigningKey() *Key { return ks.signing }
+
+// Sign signs claims with the signing key and stamps its kid in the header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(ks.signing.Method, claims)
+	if ks.signing.ID != "" {
+		t.Header["kid"] = ks.signing.ID
+	}
+	return t.SignedString(ks.signing.signKey)
+}
+
+// Parse verifies tokenStr against the key named by its kid and decodes it
+// into claims. Tokens must carry an expiry, and their algorithm must match
+// the key's so a public key can never be used as an HMAC secret. opts add
+// checks such as the expected issuer or audience.
+func (ks *KeySet) Parse(tokenStr string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	opts = append([]jwt.ParserOption{
+		jwt.WithValidMethods(ks.methods()),
+		jwt.WithExpirationRequired(),
+	}, opts...)
+	tok, err := jwt.ParseWithClaims(tokenStr, claims, ks.keyfunc, opts...)
+	if err != nil {
+		return err
+	}
+	if !tok.Valid {
+		return jwt.ErrTokenSignatureInvalid
+	}
+	return nil
+}
+
+func (ks *KeySet) keyfunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if t.Method.Alg() != k.Method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return k.verifyKey, nil
+}
+
+func (ks *KeySet) methods() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, k := range ks.keys {
+		if alg := k.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			out = append(out, alg)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// sortedKeys returns the keys ordered by kid, for stable output.
+func (ks *KeySet) sortedKeys() []*Key {
+	out := make([]*Key, 0, len(ks.keys))
+	for _, k := range ks.keys {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
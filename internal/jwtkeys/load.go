@@ -0,0 +1,89 @@
+package jwtkeys
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Load builds the key set from configuration.
+//
+// With no dir, tokens are signed with legacySecret using HS256. Otherwise
+// every *.pem file in dir is a key whose kid is the file name without the
+// extension: private keys (PKCS#8, or PKCS#1 for RSA) can sign, public keys
+// (PKIX) only verify. signingKID picks the signing key. If legacySecret is
+// also set it stays valid for verifying tokens issued before the switch.
+func Load(dir, signingKID, legacySecret string) (*KeySet, error) {
+	if dir == "" {
+		if legacySecret == "" {
+			return nil, errors.New("jwtkeys: JWT_SECRET or JWT_KEYS_DIR is required")
+		}
+		return NewHMAC([]byte(legacySecret)), nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := newKeySet()
+	for _, p := range paths {
+		kid := strings.TrimSuffix(filepath.Base(p), ".pem")
+		k, err := loadKey(kid, p)
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[kid] = k
+	}
+
+	signing, ok := ks.keys[signingKID]
+	if !ok {
+		return nil, fmt.Errorf("jwtkeys: signing key %q not found in %s", signingKID, dir)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("jwtkeys: signing key %q is a public key", signingKID)
+	}
+	ks.signing = signing
+
+	if legacySecret != "" {
+		k := hmacKey([]byte(legacySecret))
+		k.signKey = nil
+		ks.keys[k.ID] = k
+	}
+	return ks, nil
+}
+
+func loadKey(kid, path string) (*Key, error) {
+	if kid == "" {
+		return nil, fmt.Errorf("jwtkeys: %s: empty kid", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwtkeys: %s: no PEM block", path)
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jwtkeys: %s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jwtkeys: %s: %w", path, err)
+	}
+	return newKey(kid, key)
+}
@@ -0,0 +1,18 @@
+package mail
+
+import (
+	"context"
+	"log"
+)
+
+// LogMailer writes messages to the standard logger instead of sending them.
+type LogMailer struct {
+	from string
+}
+
+func NewLogMailer(from string) *LogMailer { return &LogMailer{from: from} }
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("mail from=%s to=%s subject=%q\n%s", m.from, msg.To, msg.Subject, msg.Body)
+	return nil
+}
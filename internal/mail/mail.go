@@ -0,0 +1,29 @@
+// Package mail sends transactional email. Only development transports live
+// here; a real provider plugs in by implementing Mailer.
+package mail
+
+import (
+	"context"
+	"fmt"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// New returns the mailer named by kind: "log" (the default) or "file".
+func New(kind, dir, from string) (Mailer, error) {
+	switch kind {
+	case "", "log":
+		return NewLogMailer(from), nil
+	case "file":
+		return NewFileMailer(dir, from), nil
+	}
+	return nil, fmt.Errorf("mail: unknown mailer %q", kind)
+}
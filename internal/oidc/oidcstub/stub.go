@@ -0,0 +1,300 @@
+// Package oidcstub is a minimal OpenID provider for tests. It signs everyone
+// in without asking: the user is picked by the login_hint parameter of the
+// authorization request. It does check what a real provider would check of
+// the client (redirect URI, PKCE, single-use codes), so the relying party
+// side can be exercised end to end.
+//
+// It is never mounted by the app: anyone who can reach it can sign in as
+// anyone.
+package oidcstub
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/httpx"
+	"github.com/angelchiav/go-ecommerce/internal/jwtkeys"
+	"github.com/angelchiav/go-ecommerce/internal/oidc"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	codeTTL    = time.Minute
+	idTokenTTL = 5 * time.Minute
+
+	// DefaultEmail signs in when the authorization request has no login_hint.
+	DefaultEmail = "stub.user@example.com"
+)
+
+// User is an account at the stub provider.
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type grant struct {
+	user        User
+	clientID    string
+	redirectURI string
+	nonce       string
+	challenge   string
+	expiresAt   time.Time
+}
+
+type Server struct {
+	issuer string
+	keys   *jwtkeys.KeySet
+
+	// Now is the clock; nil means time.Now.
+	Now func() time.Time
+	// EditClaims, if set, may change an ID token's claims before it is
+	// signed, so tests can check how relying parties treat bad tokens.
+	EditClaims func(jwt.MapClaims)
+
+	mu    sync.Mutex
+	users map[string]User
+	codes map[string]grant
+}
+
+// New returns a provider that identifies itself as issuer, the URL its
+// handlers are served under.
+func New(issuer string) (*Server, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := jwtkeys.NewSigning("stub", priv)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		issuer: strings.TrimSuffix(issuer, "/"),
+		keys:   keys,
+		users:  map[string]User{},
+		codes:  map[string]grant{},
+	}, nil
+}
+
+// AddUser registers u, replacing any user with the same email. Unknown
+// emails signing in get a verified account made up on the spot.
+func (s *Server) AddUser(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[strings.ToLower(u.Email)] = u
+}
+
+// Handler serves the provider's endpoints relative to the issuer URL.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.Discovery)
+	mux.HandleFunc("GET /authorize", s.Authorize)
+	mux.HandleFunc("POST /token", s.Token)
+	mux.HandleFunc("GET /jwks", s.JWKS)
+	return mux
+}
+
+func (s *Server) Discovery(w http.ResponseWriter, r *http.Request) {
+	httpx.JSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"EdDSA"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) JWKS(w http.ResponseWriter, r *http.Request) {
+	httpx.JSON(w, http.StatusOK, s.keys.JWKS())
+}
+
+// Authorize signs the login_hint user in and redirects back with a code.
+func (s *Server) Authorize(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	redirectURI := qs.Get("redirect_uri")
+	back, err := url.Parse(redirectURI)
+	if err != nil || !back.IsAbs() {
+		httpx.Error(w, http.StatusBadRequest, "invalid_redirect_uri")
+		return
+	}
+	if qs.Get("client_id") == "" {
+		httpx.Error(w, http.StatusBadRequest, "invalid_client")
+		return
+	}
+
+	fail := func(code string) {
+		v := back.Query()
+		v.Set("error", code)
+		v.Set("state", qs.Get("state"))
+		back.RawQuery = v.Encode()
+		http.Redirect(w, r, back.String(), http.StatusFound)
+	}
+	if qs.Get("response_type") != "code" {
+		fail("unsupported_response_type")
+		return
+	}
+	if !strings.Contains(" "+qs.Get("scope")+" ", " openid ") {
+		fail("invalid_scope")
+		return
+	}
+	if qs.Get("code_challenge") == "" || qs.Get("code_challenge_method") != "S256" {
+		fail("invalid_request")
+		return
+	}
+
+	code, err := randomString()
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	s.mu.Lock()
+	s.codes[code] = grant{
+		user:        s.user(qs.Get("login_hint")),
+		clientID:    qs.Get("client_id"),
+		redirectURI: redirectURI,
+		nonce:       qs.Get("nonce"),
+		challenge:   qs.Get("code_challenge"),
+		expiresAt:   s.now().Add(codeTTL),
+	}
+	s.mu.Unlock()
+
+	v := back.Query()
+	v.Set("code", code)
+	v.Set("state", qs.Get("state"))
+	back.RawQuery = v.Encode()
+	http.Redirect(w, r, back.String(), http.StatusFound)
+}
+
+// SignIn follows authURL, a relying party's authorization request, as a
+// browser would with email as the login_hint, and returns the code and state
+// the provider redirects back with.
+func (s *Server) SignIn(authURL, email string) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	qs := u.Query()
+	qs.Set("login_hint", email)
+	u.RawQuery = qs.Encode()
+
+	rec := httptest.NewRecorder()
+	s.Authorize(rec, httptest.NewRequest(http.MethodGet, u.String(), nil))
+	if rec.Code != http.StatusFound {
+		return "", "", fmt.Errorf("oidcstub: authorize: %d %s", rec.Code, rec.Body)
+	}
+	back, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	if e := back.Query().Get("error"); e != "" {
+		return "", "", fmt.Errorf("oidcstub: authorize: %s", e)
+	}
+	return back.Query().Get("code"), back.Query().Get("state"), nil
+}
+
+// Token exchanges a code for an ID token.
+func (s *Server) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		httpx.Error(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	clientID := r.PostForm.Get("client_id")
+	if id, _, ok := r.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(id)
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	g, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	if !ok || !s.now().Before(g.expiresAt) ||
+		g.clientID != clientID ||
+		g.redirectURI != r.PostForm.Get("redirect_uri") ||
+		oidc.Challenge(r.PostForm.Get("code_verifier")) != g.challenge {
+		httpx.Error(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	now := s.now()
+	claims := jwt.MapClaims{
+		"iss":            s.issuer,
+		"sub":            g.user.Subject,
+		"aud":            g.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(idTokenTTL).Unix(),
+		"nonce":          g.nonce,
+		"email":          g.user.Email,
+		"email_verified": g.user.EmailVerified,
+	}
+	if s.EditClaims != nil {
+		s.EditClaims(claims)
+	}
+	idToken, err := s.keys.Sign(claims)
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	access, err := randomString()
+	if err != nil {
+		httpx.Error(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	httpx.JSON(w, http.StatusOK, map[string]any{
+		"access_token": access,
+		"token_type":   "Bearer",
+		"expires_in":   int(idTokenTTL / time.Second),
+		"id_token":     idToken,
+	})
+}
+
+// user looks up email, making up a verified user for unknown ones. Callers
+// hold s.mu.
+func (s *Server) user(email string) User {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		email = DefaultEmail
+	}
+	if u, ok := s.users[email]; ok {
+		return u
+	}
+	u := User{Subject: "stub|" + email, Email: email, EmailVerified: true}
+	s.users[email] = u
+	return u
+}
+
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
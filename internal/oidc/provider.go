@@ -0,0 +1,285 @@
+// Package oidc is a small OpenID Connect relying party: the authorization
+// code flow with PKCE, and ID token verification against the provider's
+// published keys.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/jwtkeys"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrExchange       = errors.New("oidc: code exchange failed")
+	ErrIDTokenInvalid = errors.New("oidc: invalid id token")
+)
+
+// jwksRefreshInterval limits how often an unknown kid makes us refetch the
+// provider's keys.
+const jwksRefreshInterval = time.Minute
+
+// Config describes a provider registered with us as a client.
+type Config struct {
+	// Name identifies the provider in our URLs and in linked identities.
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes requested besides "openid"; defaults to email and profile.
+	Scopes []string
+}
+
+// Identity is what a verified ID token says about the user.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type idTokenClaims struct {
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"`
+	jwt.RegisteredClaims
+}
+
+// Provider talks to one OpenID provider. Its discovery document and keys
+// are fetched on first use, so the provider being down doesn't stop us
+// starting.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu        sync.Mutex
+	meta      *discovery
+	keys      *jwtkeys.KeySet
+	keysFetch time.Time
+}
+
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"email", "profile"}
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+func (p *Provider) Name() string { return p.cfg.Name }
+
+// AuthCodeURL is where to send the user to sign in. state and nonce tie the
+// callback and ID token to this attempt; challenge is the PKCE S256
+// challenge.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.cfg.ClientID)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("scope", strings.Join(append([]string{"openid"}, p.cfg.Scopes...), " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", challenge)
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + v.Encode(), nil
+}
+
+// Exchange trades an authorization code for tokens and returns the
+// identity from the verified ID token.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s: %s", ErrExchange, resp.Status, body)
+	}
+
+	var tok struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	if tok.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token", ErrExchange)
+	}
+
+	return p.verify(ctx, meta, tok.IDToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, meta *discovery, idToken, nonce string) (*Identity, error) {
+	keys, err := p.jwks(ctx, meta, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims idTokenClaims
+	opts := []jwt.ParserOption{
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	}
+	err = keys.Parse(idToken, &claims, opts...)
+	if errors.Is(err, jwtkeys.ErrUnknownKey) {
+		// the provider may have rotated its keys
+		if keys, err = p.jwks(ctx, meta, true); err != nil {
+			return nil, err
+		}
+		claims = idTokenClaims{}
+		err = keys.Parse(idToken, &claims, opts...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIDTokenInvalid, err)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrIDTokenInvalid)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrIDTokenInvalid)
+	}
+
+	return &Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: isTrue(claims.EmailVerified),
+	}, nil
+}
+
+// isTrue reads email_verified, which some providers send as a string.
+func isTrue(v any) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+	return false
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	meta := p.meta
+	p.mu.Unlock()
+	if meta != nil {
+		return meta, nil
+	}
+
+	var d discovery
+	u := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, u, &d); err != nil {
+		return nil, err
+	}
+	if d.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: %s: discovery issuer %q does not match %q", p.cfg.Name, d.Issuer, p.cfg.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc: %s: incomplete discovery document", p.cfg.Name)
+	}
+
+	p.mu.Lock()
+	p.meta = &d
+	p.mu.Unlock()
+	return &d, nil
+}
+
+// jwks returns the provider's keys, fetching them if they aren't cached or,
+// with refresh, if the cached copy is old enough to be refetched.
+func (p *Provider) jwks(ctx context.Context, meta *discovery, refresh bool) (*jwtkeys.KeySet, error) {
+	p.mu.Lock()
+	keys, fetched := p.keys, p.keysFetch
+	p.mu.Unlock()
+	if keys != nil && (!refresh || time.Since(fetched) < jwksRefreshInterval) {
+		return keys, nil
+	}
+
+	var set jwtkeys.JWKS
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys, err := jwtkeys.FromJWKS(set)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.keys, p.keysFetch = keys, time.Now()
+	p.mu.Unlock()
+	return keys, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(dst)
+}
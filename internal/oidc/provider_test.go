@@ -0,0 +1,144 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/oidc"
+	"github.com/angelchiav/go-ecommerce/internal/oidc/oidcstub"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "shop"
+	testRedirect = "https://shop.example/v1/auth/oidc/stub/callback"
+)
+
+// newStub serves a stub provider and returns it with a Provider configured
+// as its client.
+func newStub(t *testing.T) (*oidcstub.Server, *oidc.Provider) {
+	t.Helper()
+	var h http.Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	stub, err := oidcstub.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = stub.Handler()
+
+	p := oidc.NewProvider(oidc.Config{
+		Name:        "stub",
+		Issuer:      srv.URL,
+		ClientID:    testClientID,
+		RedirectURL: testRedirect,
+	}, srv.Client())
+	return stub, p
+}
+
+// signIn runs the authorization request for email and returns the code,
+// along with the verifier and nonce it was started with.
+func signIn(t *testing.T, stub *oidcstub.Server, p *oidc.Provider, email string) (code, verifier, nonce string) {
+	t.Helper()
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce = "nonce-" + email
+	u, err := p.AuthCodeURL(context.Background(), "state", nonce, oidc.Challenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, state, err := stub.SignIn(u, email)
+	if err != nil {
+		t.Fatalf("SignIn: %v", err)
+	}
+	if state != "state" {
+		t.Fatalf("state = %q, want %q", state, "state")
+	}
+	return code, verifier, nonce
+}
+
+func TestExchange(t *testing.T) {
+	stub, p := newStub(t)
+	stub.AddUser(oidcstub.User{Subject: "sub-1", Email: "ada@example.com", EmailVerified: true})
+
+	code, verifier, nonce := signIn(t, stub, p, "ada@example.com")
+	id, err := p.Exchange(context.Background(), code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	want := oidc.Identity{Subject: "sub-1", Email: "ada@example.com", EmailVerified: true}
+	if *id != want {
+		t.Errorf("Exchange = %+v, want %+v", *id, want)
+	}
+
+	// codes are single use
+	if _, err := p.Exchange(context.Background(), code, verifier, nonce); !errors.Is(err, oidc.ErrExchange) {
+		t.Errorf("second Exchange = %v, want ErrExchange", err)
+	}
+}
+
+func TestExchangeEmailNotVerified(t *testing.T) {
+	stub, p := newStub(t)
+	stub.AddUser(oidcstub.User{Subject: "sub-2", Email: "bob@example.com", EmailVerified: false})
+
+	code, verifier, nonce := signIn(t, stub, p, "bob@example.com")
+	id, err := p.Exchange(context.Background(), code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if id.EmailVerified {
+		t.Error("EmailVerified = true, want false")
+	}
+}
+
+func TestExchangeWrongVerifier(t *testing.T) {
+	stub, p := newStub(t)
+
+	code, _, nonce := signIn(t, stub, p, "ada@example.com")
+	other, err := oidc.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Exchange(context.Background(), code, other, nonce); !errors.Is(err, oidc.ErrExchange) {
+		t.Errorf("Exchange = %v, want ErrExchange", err)
+	}
+}
+
+func TestExchangeRejectsBadIDTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		nonce string
+		edit  func(jwt.MapClaims)
+	}{
+		{name: "wrong nonce", nonce: "someone else's nonce"},
+		{name: "wrong audience", edit: func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{name: "wrong issuer", edit: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }},
+		{name: "no exp", edit: func(c jwt.MapClaims) { delete(c, "exp") }},
+		{name: "expired", edit: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{name: "no subject", edit: func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, p := newStub(t)
+			stub.EditClaims = tt.edit
+
+			code, verifier, nonce := signIn(t, stub, p, "ada@example.com")
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			_, err := p.Exchange(context.Background(), code, verifier, nonce)
+			if !errors.Is(err, oidc.ErrIDTokenInvalid) {
+				t.Errorf("Exchange = %v, want ErrIDTokenInvalid", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/angelchiav/go-ecommerce/internal/mail"
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrWrongPassword           = errors.New("invalid_current_password")
+	ErrEmailRequired           = errors.New("email_required")
+	ErrEmailUnchanged          = errors.New("email_unchanged")
+	ErrEmailChangeTokenInvalid = errors.New("invalid_email_change_token")
+)
+
+// ChangePassword replaces the password after checking the current one. All
+// sessions are ended, including the caller's, so it gets a fresh token pair
+// back to stay logged in.
+func (s *AuthService) ChangePassword(ctx context.Context, userID int64, current, password string) (*TokenPair, error) {
+	if len(password) < minPasswordLen {
+		return nil, ErrPasswordTooShort
+	}
+	if err := s.checkPassword(ctx, userID, current); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	if err := qtx.UpdateUserPassword(ctx, sqlc.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: string(hash),
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.endSessions(ctx, qtx, userID); err != nil {
+		return nil, err
+	}
+
+	u, err := qtx.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	family, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	pair, err := s.issue(ctx, qtx, u.ID, u.TokenVersion, family)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.revocations.forget(userID)
+	return pair, nil
+}
+
+// RequestEmailChange starts moving the account to a new address. Nothing
+// changes until the link mailed to the new address is followed; the old
+// address is told about the request.
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID int64, newEmail, password string) error {
+	newEmail = normalizeEmail(newEmail)
+	if newEmail == "" {
+		return ErrEmailRequired
+	}
+	if err := s.checkPassword(ctx, userID, password); err != nil {
+		return err
+	}
+
+	u, err := s.q.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if newEmail == u.Email {
+		return ErrEmailUnchanged
+	}
+
+	_, err = s.q.GetUserByEmail(ctx, newEmail)
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	if err := qtx.InvalidateEmailChangeTokens(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.CreateEmailChangeToken(ctx, sqlc.CreateEmailChangeTokenParams{
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: hashToken(token),
+		ExpiresAt: s.now().Add(s.opts.EmailChangeTTL),
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	link := s.opts.AppURL + "/confirm-email?token=" + url.QueryEscape(token)
+	s.sendMail(ctx, mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Follow this link within %s to make this the email address of your account:\n\n%s\n",
+			s.opts.EmailChangeTTL, link),
+	})
+	s.sendMail(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("Someone asked to change the email address of your account to %s.\n\n"+
+			"If it wasn't you, reset your password right away.\n", newEmail),
+	})
+	return nil
+}
+
+// ConfirmEmailChange applies the change a token from RequestEmailChange was
+// issued for. Each token works once.
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, token string) error {
+	if token == "" {
+		return ErrEmailChangeTokenInvalid
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	ch, err := qtx.ConsumeEmailChangeToken(ctx, hashToken(token))
+	if err == sql.ErrNoRows {
+		return ErrEmailChangeTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	// the address may have been registered since the change was requested
+	_, err = qtx.GetUserByEmail(ctx, ch.NewEmail)
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	// following the link proves the new address, so it counts as verified
+	err = qtx.UpdateUserEmail(ctx, sqlc.UpdateUserEmailParams{
+		ID:    ch.UserID,
+		Email: ch.NewEmail,
+	})
+	if isUniqueViolation(err) {
+		// registered by a concurrent request since the check above
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	if err := qtx.InvalidateEmailVerificationTokens(ctx, ch.UserID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *AuthService) checkPassword(ctx context.Context, userID int64, password string) error {
+	hash, err := s.q.GetUserPasswordHash(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return ErrWrongPassword
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/mail"
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+// deletionBatchSize bounds how many accounts one purge run anonymises.
+const deletionBatchSize = 100
+
+// RequestDeletion schedules the user's account for deletion after the grace
+// period and logs it out everywhere. Signing in again before then cancels
+// the deletion. Accounts with a password must confirm it.
+func (s *AuthService) RequestDeletion(ctx context.Context, userID int64, password string) (time.Time, error) {
+	hash, err := s.q.GetUserPasswordHash(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if hash != "" {
+		if err := s.checkPassword(ctx, userID, password); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	u, err := s.q.GetUserByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	requested, err := qtx.RequestAccountDeletion(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := s.endSessions(ctx, qtx, userID); err != nil {
+		return time.Time{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, err
+	}
+	s.revocations.forget(userID)
+
+	deleteAfter := requested.Time.Add(s.opts.DeletionGrace)
+	s.sendMail(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Your account will be deleted",
+		Body: fmt.Sprintf("You asked us to delete your account. It will be deleted after %s.\n\n"+
+			"Changed your mind? Sign in before then and the deletion is cancelled.\n\n"+
+			"Your order records are kept for accounting, without your name or email.\n",
+			deleteAfter.UTC().Format(time.RFC1123)),
+	})
+	return deleteAfter, nil
+}
+
+// cancelDeletion keeps an account scheduled for deletion when its owner
+// signs in again during the grace period.
+func (s *AuthService) cancelDeletion(ctx context.Context, q *sqlc.Queries, userID int64) error {
+	n, err := q.CancelAccountDeletion(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("account deletion for user %d cancelled by sign-in", userID)
+	}
+	return nil
+}
+
+// PurgeDeletedAccounts anonymises accounts whose grace period is over; it
+// is meant to be run by a Sweeper. The users row stays, with the email and
+// password replaced and the profile cleared, so orders keep their owner for
+// accounting. Order addresses lose the name, street and phone but keep the
+// city, region, postal code and country that tax records need; everything
+// else tied to the account is deleted.
+func (s *AuthService) PurgeDeletedAccounts(ctx context.Context) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	ids, err := qtx.ListAccountsDueForDeletion(ctx, sqlc.ListAccountsDueForDeletionParams{
+		Before:    s.now().Add(-s.opts.DeletionGrace),
+		BatchSize: deletionBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := anonymizeUser(ctx, qtx, id); err != nil {
+			return 0, fmt.Errorf("user %d: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		s.revocations.forget(id)
+	}
+	return int64(len(ids)), nil
+}
+
+func anonymizeUser(ctx context.Context, qtx *sqlc.Queries, userID int64) error {
+	steps := []func(context.Context, int64) error{
+		qtx.DeleteUserAddresses,
+		qtx.AnonymizeUserOrderAddresses,
+		qtx.DeleteUserCarts,
+		qtx.DeleteUserIdentities,
+		qtx.DeleteUserRoles,
+		qtx.DeleteUserTotp,
+		qtx.DeleteRecoveryCodes,
+		qtx.DeleteUserMfaChallenges,
+		qtx.DeleteUserRefreshTokens,
+		qtx.DeleteUserPasswordResetTokens,
+		qtx.DeleteUserEmailChangeTokens,
+		qtx.DeleteUserEmailVerificationTokens,
+		qtx.AnonymizeUser,
+	}
+	for _, step := range steps {
+		if err := step(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
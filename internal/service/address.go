@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+var (
+	ErrAddressNotFound    = errors.New("address_not_found")
+	ErrAddressLimit       = errors.New("address_limit_reached")
+	ErrFullNameInvalid    = errors.New("full_name_invalid")
+	ErrCompanyInvalid     = errors.New("company_invalid")
+	ErrLine1Invalid       = errors.New("line1_invalid")
+	ErrLine2Invalid       = errors.New("line2_invalid")
+	ErrCityInvalid        = errors.New("city_invalid")
+	ErrRegionInvalid      = errors.New("region_invalid")
+	ErrPostalCodeInvalid  = errors.New("postal_code_invalid")
+	ErrCountryUnsupported = errors.New("country_unsupported")
+	ErrPhoneInvalid       = errors.New("phone_invalid")
+)
+
+const (
+	maxAddresses       = 20
+	maxNameLen         = 100
+	maxAddressLineLen  = 200
+	maxPostalCodeLen   = 16
+	minPhoneDigits     = 6
+	maxPhoneDigits     = 15
+	maxPhoneFormatting = 10
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ().-]*$`)
+
+// PostalAddress is where a parcel or an invoice goes.
+type PostalAddress struct {
+	FullName   string `json:"full_name"`
+	Company    string `json:"company"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone"`
+}
+
+// Address is an entry in a user's address book. Any address can be used for
+// shipping or billing; the default flags pick the one checkout uses when the
+// order doesn't name one.
+type Address struct {
+	ID int64 `json:"id"`
+	PostalAddress
+	IsDefaultShipping bool      `json:"is_default_shipping"`
+	IsDefaultBilling  bool      `json:"is_default_billing"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type AddressInput struct {
+	PostalAddress
+	DefaultShipping bool
+	DefaultBilling  bool
+}
+
+type AddressService struct {
+	db *sql.DB
+	q  *sqlc.Queries
+}
+
+func NewAddressService(db *sql.DB, q *sqlc.Queries) *AddressService {
+	return &AddressService{db: db, q: q}
+}
+
+func (s *AddressService) List(ctx context.Context, userID int64) ([]Address, error) {
+	rows, err := s.q.ListAddresses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Address, 0, len(rows))
+	for _, a := range rows {
+		out = append(out, addressView(a))
+	}
+	return out, nil
+}
+
+func (s *AddressService) Get(ctx context.Context, userID, id int64) (*Address, error) {
+	a, err := s.q.GetAddress(ctx, sqlc.GetAddressParams{ID: id, UserID: userID})
+	if err == sql.ErrNoRows {
+		return nil, ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	v := addressView(a)
+	return &v, nil
+}
+
+// Create adds an address to the book. A user's first address becomes their
+// default for both shipping and billing.
+func (s *AddressService) Create(ctx context.Context, userID int64, in AddressInput) (*Address, error) {
+	if err := in.normalize(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	// serialises address changes per user, so the limit and the defaults
+	// hold under concurrent requests
+	if _, err := qtx.LockUserAddresses(ctx, userID); err != nil {
+		return nil, err
+	}
+	n, err := qtx.CountAddresses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxAddresses {
+		return nil, ErrAddressLimit
+	}
+	if n == 0 {
+		in.DefaultShipping, in.DefaultBilling = true, true
+	}
+	if err := clearDefaults(ctx, qtx, userID, in); err != nil {
+		return nil, err
+	}
+
+	a, err := qtx.CreateAddress(ctx, sqlc.CreateAddressParams{
+		UserID:            userID,
+		FullName:          in.FullName,
+		Company:           in.Company,
+		Line1:             in.Line1,
+		Line2:             in.Line2,
+		City:              in.City,
+		Region:            in.Region,
+		PostalCode:        in.PostalCode,
+		Country:           in.Country,
+		Phone:             in.Phone,
+		IsDefaultShipping: in.DefaultShipping,
+		IsDefaultBilling:  in.DefaultBilling,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	v := addressView(a)
+	return &v, nil
+}
+
+// Update replaces an address.
+func (s *AddressService) Update(ctx context.Context, userID, id int64, in AddressInput) (*Address, error) {
+	if err := in.normalize(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	if _, err := qtx.LockUserAddresses(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := clearDefaults(ctx, qtx, userID, in); err != nil {
+		return nil, err
+	}
+
+	a, err := qtx.UpdateAddress(ctx, sqlc.UpdateAddressParams{
+		ID:                id,
+		UserID:            userID,
+		FullName:          in.FullName,
+		Company:           in.Company,
+		Line1:             in.Line1,
+		Line2:             in.Line2,
+		City:              in.City,
+		Region:            in.Region,
+		PostalCode:        in.PostalCode,
+		Country:           in.Country,
+		Phone:             in.Phone,
+		IsDefaultShipping: in.DefaultShipping,
+		IsDefaultBilling:  in.DefaultBilling,
+	})
+	if err == sql.ErrNoRows {
+		return nil, ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	v := addressView(a)
+	return &v, nil
+}
+
+// Delete removes an address. Deleting a default leaves the user without one
+// until they pick another.
+func (s *AddressService) Delete(ctx context.Context, userID, id int64) error {
+	n, err := s.q.DeleteAddress(ctx, sqlc.DeleteAddressParams{ID: id, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAddressNotFound
+	}
+	return nil
+}
+
+// clearDefaults takes the default flags in makes off the user's other
+// addresses.
+func clearDefaults(ctx context.Context, qtx *sqlc.Queries, userID int64, in AddressInput) error {
+	if in.DefaultShipping {
+		if err := qtx.ClearDefaultShippingAddress(ctx, userID); err != nil {
+			return err
+		}
+	}
+	if in.DefaultBilling {
+		if err := qtx.ClearDefaultBillingAddress(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalize tidies the address and checks it against the rules for its
+// country.
+func (a *PostalAddress) normalize() error {
+	a.FullName = collapseSpace(a.FullName)
+	a.Company = collapseSpace(a.Company)
+	a.Line1 = collapseSpace(a.Line1)
+	a.Line2 = collapseSpace(a.Line2)
+	a.City = collapseSpace(a.City)
+	a.Region = collapseSpace(a.Region)
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.Phone = strings.TrimSpace(a.Phone)
+
+	switch {
+	case !validText(a.FullName, 1, maxNameLen):
+		return ErrFullNameInvalid
+	case !validText(a.Company, 0, maxNameLen):
+		return ErrCompanyInvalid
+	case !validText(a.Line1, 1, maxAddressLineLen):
+		return ErrLine1Invalid
+	case !validText(a.Line2, 0, maxAddressLineLen):
+		return ErrLine2Invalid
+	case !validText(a.City, 1, maxNameLen):
+		return ErrCityInvalid
+	case !validText(a.Region, 0, maxNameLen):
+		return ErrRegionInvalid
+	case !validPhone(a.Phone):
+		return ErrPhoneInvalid
+	}
+
+	rules, ok := shippingCountries[a.Country]
+	if !ok {
+		return ErrCountryUnsupported
+	}
+	if rules.regions != nil {
+		a.Region = strings.ToUpper(a.Region)
+		if !rules.regions[a.Region] {
+			return ErrRegionInvalid
+		}
+	}
+
+	a.PostalCode = normalizePostalCode(a.PostalCode, a.Country)
+	if a.PostalCode == "" && rules.postalOptional {
+		return nil
+	}
+	if len(a.PostalCode) > maxPostalCodeLen || !rules.postal.MatchString(a.PostalCode) {
+		return ErrPostalCodeInvalid
+	}
+	return nil
+}
+
+func normalizePostalCode(code, country string) string {
+	code = strings.ToUpper(collapseSpace(code))
+	inward, ok := postalSpacing[country]
+	if !ok {
+		return code
+	}
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) <= inward {
+		return code
+	}
+	return code[:len(code)-inward] + " " + code[len(code)-inward:]
+}
+
+// collapseSpace trims s and squeezes runs of whitespace to single spaces.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// validText reports whether s is between minLen and maxLen characters and
+// free of control characters.
+func validText(s string, minLen, maxLen int) bool {
+	n := utf8.RuneCountInString(s)
+	if n < minLen || n > maxLen || !utf8.ValidString(s) {
+		return false
+	}
+	return strings.IndexFunc(s, unicode.IsControl) < 0
+}
+
+// validPhone accepts an empty number or one written with digits, an optional
+// leading +, and the usual separators.
+func validPhone(s string) bool {
+	if s == "" {
+		return true
+	}
+	if !phonePattern.MatchString(s) {
+		return false
+	}
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits >= minPhoneDigits && digits <= maxPhoneDigits && len(s)-digits <= maxPhoneFormatting
+}
+
+func addressView(a sqlc.Address) Address {
+	return Address{
+		ID: a.ID,
+		PostalAddress: PostalAddress{
+			FullName:   a.FullName,
+			Company:    a.Company,
+			Line1:      a.Line1,
+			Line2:      a.Line2,
+			City:       a.City,
+			Region:     a.Region,
+			PostalCode: a.PostalCode,
+			Country:    a.Country,
+			Phone:      a.Phone,
+		},
+		IsDefaultShipping: a.IsDefaultShipping,
+		IsDefaultBilling:  a.IsDefaultBilling,
+		CreatedAt:         a.CreatedAt,
+		UpdatedAt:         a.UpdatedAt,
+	}
+}
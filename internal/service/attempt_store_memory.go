@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryAttemptStore keeps counters in process memory. They are lost on
+// restart and not shared between instances, so it suits a single instance
+// or development.
+type MemoryAttemptStore struct {
+	mu      sync.Mutex
+	entries map[string]AttemptState
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{entries: map[string]AttemptState{}}
+}
+
+func (s *MemoryAttemptStore) Get(ctx context.Context, key string) (AttemptState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries[key], nil
+}
+
+func (s *MemoryAttemptStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (AttemptState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.entries[key]
+	if now.Sub(st.LastFailure) > window {
+		st.Failures = 0
+	}
+	st.Failures++
+	st.LastFailure = now
+	s.entries[key] = st
+	return st, nil
+}
+
+func (s *MemoryAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.entries[key]
+	st.LockedUntil = until
+	s.entries[key] = st
+	return nil
+}
+
+func (s *MemoryAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryAttemptStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for key, st := range s.entries {
+		if st.LastFailure.Before(before) && st.LockedUntil.Before(before) {
+			delete(s.entries, key)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+// PostgresAttemptStore keeps counters in the login_attempts table, shared by
+// every instance.
+type PostgresAttemptStore struct {
+	q *sqlc.Queries
+}
+
+func NewPostgresAttemptStore(q *sqlc.Queries) *PostgresAttemptStore {
+	return &PostgresAttemptStore{q: q}
+}
+
+func (s *PostgresAttemptStore) Get(ctx context.Context, key string) (AttemptState, error) {
+	row, err := s.q.GetLoginAttempts(ctx, key)
+	if err == sql.ErrNoRows {
+		return AttemptState{}, nil
+	}
+	if err != nil {
+		return AttemptState{}, err
+	}
+	return AttemptState{
+		Failures:    int(row.Failures),
+		LastFailure: row.LastFailureAt,
+		LockedUntil: row.LockedUntil.Time,
+	}, nil
+}
+
+func (s *PostgresAttemptStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (AttemptState, error) {
+	row, err := s.q.RecordLoginFailure(ctx, sqlc.RecordLoginFailureParams{
+		Key:           key,
+		Now:           now,
+		WindowSeconds: window.Seconds(),
+	})
+	if err != nil {
+		return AttemptState{}, err
+	}
+	return AttemptState{
+		Failures:    int(row.Failures),
+		LastFailure: row.LastFailureAt,
+		LockedUntil: row.LockedUntil.Time,
+	}, nil
+}
+
+func (s *PostgresAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	return s.q.LockLoginKey(ctx, sqlc.LockLoginKeyParams{
+		Key:         key,
+		LockedUntil: sql.NullTime{Time: until, Valid: true},
+	})
+}
+
+func (s *PostgresAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.q.DeleteLoginAttempts(ctx, key)
+}
+
+func (s *PostgresAttemptStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return s.q.DeleteStaleLoginAttempts(ctx, before)
+}
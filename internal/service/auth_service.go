@@ -2,12 +2,19 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"log"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/angelchiav/go-ecommerce/internal/jwtkeys"
+	"github.com/angelchiav/go-ecommerce/internal/mail"
 	"github.com/angelchiav/go-ecommerce/internal/sqlc"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
@@ -16,20 +23,78 @@ import (
 var (
 	ErrInvalidCreds = errors.New("invalid_credentials")
 	ErrEmailTaken   = errors.New("email_taken")
+	ErrUserNotFound = errors.New("user_not_found")
+
+	ErrPasswordTooShort = errors.New("password_min_8")
+
+	ErrRefreshTokenInvalid = errors.New("invalid_refresh_token")
+	ErrRefreshTokenReused  = errors.New("refresh_token_reused")
 )
 
+const minPasswordLen = 8
+
+// dummyPasswordHash is a bcrypt hash, at the cost passwords are stored with,
+// of a password nobody has.
+const dummyPasswordHash = "$2a$10$LHp3xKYhBP8PpwvVe7uHju03/zDiI.x6ug7rx1OIKEWmAyQL4oV4a"
+
+// TokenPair is what a successful login or refresh hands back. The refresh
+// token is opaque and single use: each refresh returns a new one.
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	// UserID is who the pair was issued to; it is not sent to the client.
+	UserID int64 `json:"-"`
+}
+
+// AuthOptions are the tunables of AuthService.
+type AuthOptions struct {
+	AccessTTL        time.Duration
+	RefreshTTL       time.Duration
+	PasswordResetTTL time.Duration
+	EmailChangeTTL   time.Duration
+	VerificationTTL  time.Duration
+	// VerificationResendInterval is the minimum time between verification
+	// emails to the same user.
+	VerificationResendInterval time.Duration
+	// AppURL is the base of links sent by email.
+	AppURL string
+	// MFAIssuer names the service in authenticator apps.
+	MFAIssuer string
+	// DeletionGrace is how long a deleted account can still be restored by
+	// signing in.
+	DeletionGrace time.Duration
+	// Now is the clock; nil means time.Now.
+	Now func() time.Time
+}
+
 type AuthService struct {
-	q      *sqlc.Queries
-	secret []byte
+	q           *sqlc.Queries
+	db          *sql.DB
+	revocations *RevocationStore
+	keys        *jwtkeys.KeySet
+	mailer      mail.Mailer
+	guard       *LoginGuard
+	opts        AuthOptions
 }
 
-func NewAuthService(q *sqlc.Queries, jwtSecret string) *AuthService {
-	return &AuthService{q: q, secret: []byte(jwtSecret)}
+func NewAuthService(db *sql.DB, q *sqlc.Queries, revocations *RevocationStore, keys *jwtkeys.KeySet, mailer mail.Mailer, guard *LoginGuard, opts AuthOptions) *AuthService {
+	return &AuthService{
+		q:           q,
+		db:          db,
+		revocations: revocations,
+		keys:        keys,
+		mailer:      mailer,
+		guard:       guard,
+		opts:        opts,
+	}
 }
 
 func (s *AuthService) Register(ctx context.Context, email, password string) (int64, error) {
-	email = strings.ToLower(strings.TrimSpace(email))
-	if email == "" || len(password) < 8 {
+	email = normalizeEmail(email)
+	if email == "" || len(password) < minPasswordLen {
 		return 0, errors.New("email_required_password_min_8")
 	}
 
@@ -46,43 +111,258 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (int
 		return 0, err
 	}
 
-	u, err := s.q.CreateUser(ctx, sqlc.CreateUserParams{
+	id, err := s.q.CreateUser(ctx, sqlc.CreateUserParams{
 		Email:        email,
 		PasswordHash: string(hash),
 	})
 	if err != nil {
 		return 0, err
 	}
-	return u, nil
+
+	// the account exists either way; a failed mail can be resent
+	if err := s.sendVerification(ctx, id, email); err != nil {
+		log.Printf("verification email for user %d error: %v", id, err)
+	}
+	return id, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
-	email = strings.ToLower(strings.TrimSpace(email))
+// Login checks the credentials and starts a session. ip is the client's
+// address, used to throttle guessing across accounts. Too many failures
+// return an *AccountLockedError or *RateLimitError without checking the
+// password at all.
+func (s *AuthService) Login(ctx context.Context, email, password, ip string) (*LoginResult, error) {
+	email = normalizeEmail(email)
+	if err := s.guard.Check(ctx, email, ip); err != nil {
+		return nil, err
+	}
+
 	u, err := s.q.GetUserByEmail(ctx, email)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	// unknown emails and accounts without a password are checked against a
+	// dummy hash, so they take as long to refuse as a wrong password
+	hash := u.PasswordHash
+	if err == sql.ErrNoRows || hash == "" {
+		hash = dummyPasswordHash
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil || hash == dummyPasswordHash {
+		if err := s.guard.Fail(ctx, email, ip); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCreds
+	}
+
+	// with two factors the failure counters are only reset by VerifyMFA
+	mfa, err := s.mfaEnabled(ctx, s.q, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa {
+		return s.startMFA(ctx, u.ID)
+	}
+
+	if err := s.guard.Succeed(ctx, email); err != nil {
+		return nil, err
+	}
+
+	family, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	pair, err := s.issue(ctx, s.q, u.ID, u.TokenVersion, family)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{TokenPair: pair}, nil
+}
+
+func (s *AuthService) now() time.Time {
+	if s.opts.Now != nil {
+		return s.opts.Now()
+	}
+	return time.Now()
+}
+
+// UnlockUser lifts a login lockout on the user's account.
+func (s *AuthService) UnlockUser(ctx context.Context, userID int64) error {
+	u, err := s.q.GetUserByID(ctx, userID)
 	if err == sql.ErrNoRows {
-		return "", ErrInvalidCreds
+		return ErrUserNotFound
 	}
 	if err != nil {
-		return "", err
+		return err
+	}
+	return s.guard.Unlock(ctx, u.Email)
+}
+
+// Refresh exchanges a refresh token for a new token pair. Every refresh token
+// belongs to a family started at login; presenting one that was already
+// exchanged means it leaked, so the whole family is revoked and the holder of
+// the latest token has to log in again too.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	if refreshToken == "" {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	rt, err := qtx.GetRefreshTokenForUpdate(ctx, hashToken(refreshToken))
+	if err == sql.ErrNoRows {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rt.UsedAt.Valid && !rt.RevokedAt.Valid {
+		if err := qtx.RevokeRefreshTokenFamily(ctx, rt.FamilyID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if rt.UsedAt.Valid || rt.RevokedAt.Valid || !s.now().Before(rt.ExpiresAt) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	u, err := qtx.GetUserByID(ctx, rt.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := qtx.MarkRefreshTokenUsed(ctx, rt.ID); err != nil {
+		return nil, err
 	}
 
-	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
-		return "", ErrInvalidCreds
+	pair, err := s.issue(ctx, qtx, u.ID, u.TokenVersion, rt.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// issue signs an access token carrying the user's current permissions and
+// stores a fresh refresh token in family. Every way of signing in ends here,
+// so this is also where a pending account deletion is called off.
+func (s *AuthService) issue(ctx context.Context, q *sqlc.Queries, userID int64, version int32, family string) (*TokenPair, error) {
+	now := s.now()
+
+	if err := s.cancelDeletion(ctx, q, userID); err != nil {
+		return nil, err
+	}
+	perms, err := q.ListUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	access, err := s.accessToken(userID, perms, version, now)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	refreshExp := now.Add(s.opts.RefreshTTL)
+	if err := q.CreateRefreshToken(ctx, sqlc.CreateRefreshTokenParams{
+		UserID:    userID,
+		FamilyID:  family,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: refreshExp,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		TokenType:        "Bearer",
+		ExpiresAt:        now.Add(s.opts.AccessTTL),
+		RefreshToken:     refresh,
+		RefreshExpiresAt: refreshExp,
+		UserID:           userID,
+	}, nil
+}
+
+// Logout revokes the access token the request came with and, if given, the
+// refresh token family it belongs to.
+func (s *AuthService) Logout(ctx context.Context, userID int64, jti string, expiresAt time.Time, refreshToken string) error {
+	if refreshToken != "" {
+		if err := s.q.RevokeRefreshTokenFamilyByToken(ctx, sqlc.RevokeRefreshTokenFamilyByTokenParams{
+			TokenHash: hashToken(refreshToken),
+			UserID:    userID,
+		}); err != nil {
+			return err
+		}
+	}
+	return s.revocations.Revoke(ctx, userID, jti, expiresAt)
+}
+
+// LogoutAll ends every session of the user: all refresh tokens are revoked
+// and access tokens issued so far stop being accepted.
+func (s *AuthService) LogoutAll(ctx context.Context, userID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.endSessions(ctx, s.q.WithTx(tx), userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.revocations.forget(userID)
+	return nil
+}
+
+func (s *AuthService) accessToken(userID int64, perms []string, version int32, now time.Time) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
 	}
 
 	claims := jwt.RegisteredClaims{
-		Subject:   strconv.FormatInt(u.ID, 10),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(2 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ID:        jti,
+		Subject:   strconv.FormatInt(userID, 10),
+		ExpiresAt: jwt.NewNumericDate(now.Add(s.opts.AccessTTL)),
+		IssuedAt:  jwt.NewNumericDate(now),
 	}
 
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, struct {
-		Role string `json:"role"`
-		jwt.RegisteredClaims
-	}{
-		Role:             u.Role,
+	return s.keys.Sign(&jwtkeys.Claims{
+		Permissions:      perms,
+		Version:          version,
 		RegisteredClaims: claims,
 	})
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// randomToken returns n random bytes, base64url encoded.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return t.SignedString(s.secret)
+// hashToken is how opaque tokens are stored, so a database leak doesn't
+// hand out usable tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
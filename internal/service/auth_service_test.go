@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLoginUnknownEmail(t *testing.T) {
+	svc, _, _, _ := newMFATest(t)
+
+	// even the dummy hash's own password must not sign anyone in
+	for _, password := range []string{"whatever", "not a password anyone has"} {
+		if _, err := svc.Login(context.Background(), "nobody@example.com", password, ""); err != ErrInvalidCreds {
+			t.Errorf("Login(%q) = %v, want ErrInvalidCreds", password, err)
+		}
+	}
+}
+
+func TestLoginWithoutPassword(t *testing.T) {
+	svc, _, _, acct := newMFATest(t)
+	// accounts created through an OpenID provider have no password
+	acct.passwordHash = ""
+
+	if _, err := svc.Login(context.Background(), mfaTestEmail, "", ""); err != ErrInvalidCreds {
+		t.Errorf("Login = %v, want ErrInvalidCreds", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/angelchiav/go-ecommerce/internal/sqlc"
 )
@@ -11,34 +12,73 @@ import (
 var (
 	ErrQtyInvalid   = errors.New("qty_invalid")
 	ErrItemNotFound = errors.New("item_not_found")
+
+	errNoCart = errors.New("no active cart")
 )
 
 type CartItem struct {
-	ID             int64  `json:"id"`
-	ProductID      int64  `json:"product_id"`
-	Name           string `json:"name"`
-	Qty            int32  `json:"qty"`
-	PriceCents     int32  `json:"price_cents"`
-	LineTotalCents int32  `json:"line_total_cents"`
+	ID        int64  `json:"id"`
+	ProductID int64  `json:"product_id"`
+	Name      string `json:"name"`
+	Qty       int32  `json:"qty"`
+	Available int32  `json:"available"`
+	Price     Money  `json:"price"`
+	LineTotal Money  `json:"line_total"`
+}
+
+// CartOwner says whose cart a request is about: a signed-in user, or a guest
+// identified by the opaque token handed out with their cart.
+type CartOwner struct {
+	UserID     int64
+	GuestToken string
 }
 
+func UserCart(userID int64) CartOwner { return CartOwner{UserID: userID} }
+
+func GuestCart(token string) CartOwner { return CartOwner{GuestToken: token} }
+
+func (o CartOwner) isGuest() bool { return o.UserID == 0 }
+
+// CartView is a cart as shown to its owner. If a product's currency was
+// changed after it went in the cart, the lines no longer share a currency:
+// Total is then left out and CurrencyMismatch set, so the customer can still
+// see the cart and remove the odd line out.
 type CartView struct {
-	CartID     int64      `json:"cart_id"`
-	Items      []CartItem `json:"items"`
-	TotalCents int32      `json:"total_cents"`
+	CartID           int64      `json:"cart_id"`
+	Items            []CartItem `json:"items"`
+	Total            *Money     `json:"total,omitempty"`
+	CurrencyMismatch bool       `json:"currency_mismatch,omitempty"`
+}
+
+// StockError is returned when a cart asks for more units than are left once
+// other carts' reservations are accounted for.
+type StockError struct {
+	ProductID int64
+	Requested int32
+	Available int32
 }
 
+func (e *StockError) Error() string { return "insufficient_stock" }
+
 type CartService struct {
-	q  *sqlc.Queries
-	db *sql.DB
+	q              *sqlc.Queries
+	db             *sql.DB
+	reservationTTL time.Duration
+	guestTTL       time.Duration
 }
 
-func NewCartService(db *sql.DB, q *sqlc.Queries) *CartService {
-	return &CartService{db: db, q: q}
+func NewCartService(db *sql.DB, q *sqlc.Queries, reservationTTL, guestTTL time.Duration) *CartService {
+	return &CartService{db: db, q: q, reservationTTL: reservationTTL, guestTTL: guestTTL}
 }
 
-func (s *CartService) Get(ctx context.Context, userID int64) (*CartView, error) {
-	cartID, err := s.q.GetOrCreateActiveCart(ctx, userID)
+// Get returns the owner's cart. Guests without a cart get an empty one that
+// is not stored until they add an item.
+func (s *CartService) Get(ctx context.Context, owner CartOwner) (*CartView, error) {
+	cartID, _, err := s.resolveCart(ctx, s.q, owner, !owner.isGuest())
+	if err == errNoCart {
+		total := NewMoney(0, DefaultCurrency)
+		return &CartView{Items: []CartItem{}, Total: &total}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -49,70 +89,356 @@ func (s *CartService) Get(ctx context.Context, userID int64) (*CartView, error)
 	}
 
 	items := make([]CartItem, 0, len(rows))
-	var total int32
+	lines := make([]Money, 0, len(rows))
 
 	for _, r := range rows {
+		line := NewMoney(r.LineTotalCents, r.Currency)
 		items = append(items, CartItem{
-			ID:             r.ID,
-			ProductID:      r.ProductID,
-			Name:           r.Name,
-			Qty:            r.Qty,
-			PriceCents:     int32(r.PriceCents),
-			LineTotalCents: r.LineTotalCents,
+			ID:        r.ID,
+			ProductID: r.ProductID,
+			Name:      r.Name,
+			Qty:       r.Qty,
+			Available: max(r.Available, 0),
+			Price:     NewMoney(r.PriceCents, r.Currency),
+			LineTotal: line,
 		})
-		total += r.LineTotalCents
+		lines = append(lines, line)
 	}
-	return &CartView{
-		CartID:     cartID,
-		Items:      items,
-		TotalCents: total,
-	}, nil
+
+	cv := &CartView{
+		CartID: cartID,
+		Items:  items,
+	}
+	total, err := sumMoney(lines)
+	if err == ErrCurrencyMismatch {
+		cv.CurrencyMismatch = true
+		return cv, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cv.Total = &total
+	return cv, nil
 }
 
-func (s *CartService) AddItem(ctx context.Context, userID, productID int64, qty int32) error {
+// AddItem puts qty units of a product in the owner's cart, creating the cart
+// if there is none yet. For a new guest cart AddItem returns its token; the
+// caller must hand it to the client, as it is the only way back to the cart.
+func (s *CartService) AddItem(ctx context.Context, owner CartOwner, productID int64, qty int32) (string, error) {
 	if qty <= 0 {
-		return ErrQtyInvalid
+		return "", ErrQtyInvalid
 	}
-	cartID, err := s.q.GetActiveCartID(ctx, userID)
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return "", err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	cartID, newToken, err := s.resolveCart(ctx, qtx, owner, true)
+	if err != nil {
+		return "", err
+	}
+
+	p, err := qtx.LockActiveProduct(ctx, productID)
+	if err == sql.ErrNoRows {
+		return "", ErrProductNotFound
 	}
-	_, err = s.q.UpsertCartItem(ctx, sqlc.UpsertCartItemParams{
+	if err != nil {
+		return "", err
+	}
+
+	// a cart is priced in a single currency, fixed by its first item
+	cur, err := qtx.GetCartCurrency(ctx, cartID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if err == nil && cur != p.Currency {
+		return "", ErrCurrencyMismatch
+	}
+
+	item, err := qtx.UpsertCartItem(ctx, sqlc.UpsertCartItemParams{
 		CartID:    cartID,
 		ProductID: productID,
 		Qty:       qty,
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.reserve(ctx, qtx, cartID, p, item.Qty); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return newToken, nil
 }
 
-func (s *CartService) UpdateItemQty(ctx context.Context, userID, itemID int64, qty int32) error {
+func (s *CartService) UpdateItemQty(ctx context.Context, owner CartOwner, itemID int64, qty int32) error {
 	if qty <= 0 {
 		return ErrQtyInvalid
 	}
-	cartID, err := s.q.GetActiveCartID(ctx, userID)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	cartID, _, err := s.resolveCart(ctx, qtx, owner, false)
+	if err == errNoCart {
+		return ErrItemNotFound
+	}
 	if err != nil {
 		return err
 	}
-	if err := s.q.UpdateCartItemQtyInCart(ctx, sqlc.UpdateCartItemQtyInCartParams{
+
+	item, err := qtx.GetCartItem(ctx, sqlc.GetCartItemParams{
+		ID:     itemID,
+		CartID: cartID,
+	})
+	if err == sql.ErrNoRows {
+		return ErrItemNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	p, err := qtx.LockActiveProduct(ctx, item.ProductID)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.reserve(ctx, qtx, cartID, p, qty); err != nil {
+		return err
+	}
+
+	n, err := qtx.UpdateCartItemQtyInCart(ctx, sqlc.UpdateCartItemQtyInCartParams{
 		ID:     itemID,
 		CartID: cartID,
 		Qty:    qty,
-	}); err != nil {
+	})
+	if err != nil {
 		return err
 	}
-	return nil
+	if n == 0 {
+		return ErrItemNotFound
+	}
+	return tx.Commit()
 }
 
-func (s *CartService) DeleteItem(ctx context.Context, userID, itemID int64) error {
-	cartID, err := s.q.GetActiveCartID(ctx, userID)
+func (s *CartService) DeleteItem(ctx context.Context, owner CartOwner, itemID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	cartID, _, err := s.resolveCart(ctx, qtx, owner, false)
+	if err == errNoCart {
+		return ErrItemNotFound
+	}
 	if err != nil {
 		return err
 	}
-	if err := s.q.DeleteCartItemInCart(ctx, sqlc.DeleteCartItemInCartParams{
+	if err := qtx.DeleteReservationForCartItem(ctx, sqlc.DeleteReservationForCartItemParams{
 		ID:     itemID,
 		CartID: cartID,
 	}); err != nil {
 		return err
 	}
-	return nil
+	n, err := qtx.DeleteCartItemInCart(ctx, sqlc.DeleteCartItemInCartParams{
+		ID:     itemID,
+		CartID: cartID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrItemNotFound
+	}
+	return tx.Commit()
+}
+
+// resolveCart finds the owner's active cart. Every cart operation goes
+// through it. If there is none, it returns errNoCart, or with create set
+// makes one; a new guest cart comes with a token, returned as newToken.
+func (s *CartService) resolveCart(ctx context.Context, q *sqlc.Queries, owner CartOwner, create bool) (cartID int64, newToken string, err error) {
+	if owner.isGuest() {
+		if owner.GuestToken != "" {
+			cartID, err = q.GetGuestCartID(ctx, hashToken(owner.GuestToken))
+		} else {
+			err = sql.ErrNoRows
+		}
+		if err == sql.ErrNoRows && create {
+			newToken, cartID, err = s.createGuestCart(ctx, q)
+		}
+	} else if create {
+		cartID, err = q.GetOrCreateActiveCart(ctx, owner.UserID)
+		if err == sql.ErrNoRows {
+			// a concurrent request created the cart after our snapshot was
+			// taken; it is visible to a fresh statement
+			cartID, err = q.GetActiveCartID(ctx, owner.UserID)
+		}
+	} else {
+		cartID, err = q.GetActiveCartID(ctx, owner.UserID)
+	}
+	if err == sql.ErrNoRows {
+		return 0, "", errNoCart
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	return cartID, newToken, nil
+}
+
+func (s *CartService) createGuestCart(ctx context.Context, qtx *sqlc.Queries) (string, int64, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", 0, err
+	}
+	cartID, err := qtx.CreateGuestCart(ctx, hashToken(token))
+	if err != nil {
+		return "", 0, err
+	}
+	return token, cartID, nil
+}
+
+// MergeGuestCart moves a guest's cart into userID's active cart when they
+// sign in. Lines for a product already in the user's cart add up, as when
+// the item is added twice. Stock is held for as much of each line as is
+// available. Carts in different currencies are not merged and
+// ErrCurrencyMismatch is returned, leaving the guest cart untouched.
+func (s *CartService) MergeGuestCart(ctx context.Context, userID int64, token string) error {
+	if token == "" {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	guestID, err := qtx.LockGuestCart(ctx, hashToken(token))
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cartID, _, err := s.resolveCart(ctx, qtx, UserCart(userID), true)
+	if err != nil {
+		return err
+	}
+
+	guestCur, err := qtx.GetCartCurrency(ctx, guestID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		cur, err := qtx.GetCartCurrency(ctx, cartID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil && cur != guestCur {
+			return ErrCurrencyMismatch
+		}
+	}
+
+	// locks the guest lines and their products in product order, as
+	// checkout does
+	lines, err := qtx.LockCartItemsForCheckout(ctx, guestID)
+	if err != nil {
+		return err
+	}
+	for _, l := range lines {
+		item, err := qtx.UpsertCartItem(ctx, sqlc.UpsertCartItemParams{
+			CartID:    cartID,
+			ProductID: l.ProductID,
+			Qty:       l.Qty,
+		})
+		if err != nil {
+			return err
+		}
+
+		p, err := qtx.LockActiveProduct(ctx, l.ProductID)
+		if err == sql.ErrNoRows {
+			// inactive; checkout will say so
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.reserveAvailable(ctx, qtx, cartID, p, item.Qty); err != nil {
+			return err
+		}
+	}
+
+	// takes the guest's items and stock holds with it
+	if err := qtx.DeleteCart(ctx, guestID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeStaleGuestCarts deletes guest carts nobody has touched within the
+// guest cart lifetime; it is meant to be run by a Sweeper.
+func (s *CartService) PurgeStaleGuestCarts(ctx context.Context) (int64, error) {
+	return s.q.DeleteStaleGuestCarts(ctx, time.Now().Add(-s.guestTTL))
+}
+
+// reserveAvailable holds up to qty units of p for the cart, as many as
+// other carts leave free.
+func (s *CartService) reserveAvailable(ctx context.Context, qtx *sqlc.Queries, cartID int64, p sqlc.Product, qty int32) error {
+	err := s.reserve(ctx, qtx, cartID, p, qty)
+	var stockErr *StockError
+	if !errors.As(err, &stockErr) {
+		return err
+	}
+	if stockErr.Available == 0 {
+		return nil
+	}
+	return s.reserve(ctx, qtx, cartID, p, stockErr.Available)
+}
+
+// reserve holds qty units of p for the cart, replacing any earlier hold. The
+// caller must have locked p's row so concurrent reservations for the same
+// product are serialised.
+func (s *CartService) reserve(ctx context.Context, qtx *sqlc.Queries, cartID int64, p sqlc.Product, qty int32) error {
+	held, err := qtx.SumReservedElsewhere(ctx, sqlc.SumReservedElsewhereParams{
+		ProductID: p.ID,
+		CartID:    cartID,
+	})
+	if err != nil {
+		return err
+	}
+
+	available := p.Stock - held
+	if qty > available {
+		return &StockError{
+			ProductID: p.ID,
+			Requested: qty,
+			Available: max(available, 0),
+		}
+	}
+
+	return qtx.UpsertReservation(ctx, sqlc.UpsertReservationParams{
+		CartID:     cartID,
+		ProductID:  p.ID,
+		Qty:        qty,
+		TtlSeconds: s.reservationTTL.Seconds(),
+	})
 }
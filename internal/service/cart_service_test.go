@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+const (
+	testCartID    = int64(42)
+	testProductID = int64(10)
+)
+
+// newCartTest returns a CartService on a fake database in which product 10
+// is active, priced in USD and has plenty of stock nobody else holds.
+// UpsertCartItem records the cart it was called for in *upsertedInto.
+func newCartTest(t *testing.T) (*CartService, *fakeDB, *int64) {
+	t.Helper()
+	f, db := newFakeDB(t)
+
+	now := time.Now()
+	f.answer("LockActiveProduct", row(testProductID, "Widget", "", int64(1999), int64(50), true, now, now, "USD"))
+	f.answer("GetCartCurrency", noRows())
+	f.answer("SumReservedElsewhere", row(int64(0)))
+	f.answer("UpsertReservation", affected(1))
+
+	upsertedInto := new(int64)
+	f.on("UpsertCartItem", func(args []driver.Value) (fakeResult, error) {
+		*upsertedInto = args[0].(int64)
+		return row(int64(100), args[0], args[1], args[2]), nil
+	})
+
+	return NewCartService(db, sqlc.New(db), 15*time.Minute, 720*time.Hour), f, upsertedInto
+}
+
+func TestAddItemCreatesUserCartOnFirstUse(t *testing.T) {
+	s, f, upsertedInto := newCartTest(t)
+	f.answer("GetOrCreateActiveCart", row(testCartID))
+
+	token, err := s.AddItem(context.Background(), UserCart(7), testProductID, 2)
+	if err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	if token != "" {
+		t.Errorf("AddItem returned guest token %q for a user", token)
+	}
+	if *upsertedInto != testCartID {
+		t.Errorf("item added to cart %d, want %d", *upsertedInto, testCartID)
+	}
+	if f.committed() != 1 {
+		t.Errorf("committed %d times, want 1", f.committed())
+	}
+}
+
+func TestAddItemRetriesWhenCartCreationLosesRace(t *testing.T) {
+	s, f, upsertedInto := newCartTest(t)
+	// a concurrent request inserted the cart after our snapshot was taken,
+	// so our insert did nothing and returned no row
+	f.answer("GetOrCreateActiveCart", noRows())
+	f.answer("GetActiveCartID", row(testCartID))
+
+	if _, err := s.AddItem(context.Background(), UserCart(7), testProductID, 2); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	if *upsertedInto != testCartID {
+		t.Errorf("item added to cart %d, want %d", *upsertedInto, testCartID)
+	}
+	if n := f.called("GetActiveCartID"); n != 1 {
+		t.Errorf("GetActiveCartID ran %d times, want 1", n)
+	}
+}
+
+func TestResolveCartWithoutCreate(t *testing.T) {
+	s, f, _ := newCartTest(t)
+	f.answer("GetActiveCartID", noRows())
+
+	if _, _, err := s.resolveCart(context.Background(), s.q, UserCart(7), false); err != errNoCart {
+		t.Errorf("resolveCart = %v, want errNoCart", err)
+	}
+	if _, _, err := s.resolveCart(context.Background(), s.q, GuestCart(""), false); err != errNoCart {
+		t.Errorf("resolveCart for a guest without a token = %v, want errNoCart", err)
+	}
+	if n := f.called("GetOrCreateActiveCart") + f.called("CreateGuestCart"); n != 0 {
+		t.Errorf("resolveCart without create made %d carts", n)
+	}
+}
+
+func TestAddItemCreatesGuestCart(t *testing.T) {
+	for _, token := range []string{"", "stale-token"} {
+		s, f, upsertedInto := newCartTest(t)
+		f.answer("GetGuestCartID", noRows())
+		var storedHash any
+		f.on("CreateGuestCart", func(args []driver.Value) (fakeResult, error) {
+			storedHash = args[0]
+			return row(testCartID), nil
+		})
+
+		newToken, err := s.AddItem(context.Background(), GuestCart(token), testProductID, 1)
+		if err != nil {
+			t.Fatalf("AddItem with token %q: %v", token, err)
+		}
+		if newToken == "" || newToken == token {
+			t.Fatalf("AddItem with token %q returned token %q, want a new one", token, newToken)
+		}
+		if storedHash != hashToken(newToken) {
+			t.Error("the cart was not stored under the new token's hash")
+		}
+		if *upsertedInto != testCartID {
+			t.Errorf("item added to cart %d, want %d", *upsertedInto, testCartID)
+		}
+	}
+}
+
+func TestGetCreatesUserCartOnFirstUse(t *testing.T) {
+	s, f, _ := newCartTest(t)
+	f.answer("GetOrCreateActiveCart", row(testCartID))
+	f.answer("ListCartItems", rows())
+
+	cv, err := s.Get(context.Background(), UserCart(7))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if cv.CartID != testCartID || len(cv.Items) != 0 {
+		t.Errorf("Get = %+v, want empty cart %d", cv, testCartID)
+	}
+	if cv.Total == nil || *cv.Total != NewMoney(0, DefaultCurrency) {
+		t.Errorf("Total = %v, want 0 %s", cv.Total, DefaultCurrency)
+	}
+}
+
+func TestGetGuestWithoutCart(t *testing.T) {
+	s, f, _ := newCartTest(t)
+	f.answer("GetGuestCartID", noRows())
+
+	for _, token := range []string{"", "unknown-token"} {
+		cv, err := s.Get(context.Background(), GuestCart(token))
+		if err != nil {
+			t.Fatalf("Get with token %q: %v", token, err)
+		}
+		if cv.CartID != 0 || len(cv.Items) != 0 || cv.Total == nil {
+			t.Errorf("Get with token %q = %+v, want an unsaved empty cart", token, cv)
+		}
+	}
+}
+
+func TestGetMixedCurrencies(t *testing.T) {
+	s, f, _ := newCartTest(t)
+	f.answer("GetOrCreateActiveCart", row(testCartID))
+	f.answer("ListCartItems", rows(
+		[]driver.Value{int64(1), int64(10), int64(2), "Widget", int64(1999), "USD", int64(3998), int64(48)},
+		// its currency was changed after it went in the cart
+		[]driver.Value{int64(2), int64(11), int64(1), "Gadget", int64(500), "EUR", int64(500), int64(9)},
+	))
+
+	cv, err := s.Get(context.Background(), UserCart(7))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !cv.CurrencyMismatch || cv.Total != nil {
+		t.Errorf("Get = %+v, want no total and CurrencyMismatch", cv)
+	}
+	if len(cv.Items) != 2 || cv.Items[1].LineTotal != NewMoney(500, "EUR") {
+		t.Errorf("Items = %+v, want both lines with their own currency", cv.Items)
+	}
+}
+
+func TestItemChangesWithoutCart(t *testing.T) {
+	s, f, _ := newCartTest(t)
+	f.answer("GetActiveCartID", noRows())
+	ctx := context.Background()
+
+	for _, owner := range []CartOwner{UserCart(7), GuestCart("")} {
+		if err := s.UpdateItemQty(ctx, owner, 5, 3); err != ErrItemNotFound {
+			t.Errorf("UpdateItemQty for %+v = %v, want ErrItemNotFound", owner, err)
+		}
+		if err := s.DeleteItem(ctx, owner, 5); err != ErrItemNotFound {
+			t.Errorf("DeleteItem for %+v = %v, want ErrItemNotFound", owner, err)
+		}
+	}
+	// neither may make a cart just to find it empty
+	if n := f.called("GetOrCreateActiveCart") + f.called("CreateGuestCart"); n != 0 {
+		t.Errorf("made %d carts", n)
+	}
+}
+
+func TestUpdateItemQty(t *testing.T) {
+	tests := []struct {
+		name    string
+		updated int64
+		want    error
+		commits int
+	}{
+		{"item updated", 1, nil, 1},
+		// the item went away between reading and updating it
+		{"no row updated", 0, ErrItemNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, f, _ := newCartTest(t)
+			f.answer("GetActiveCartID", row(testCartID))
+			f.answer("GetCartItem", row(int64(5), testCartID, testProductID, int64(1)))
+			f.answer("UpdateCartItemQtyInCart", affected(tt.updated))
+
+			if err := s.UpdateItemQty(context.Background(), UserCart(7), 5, 3); err != tt.want {
+				t.Fatalf("UpdateItemQty = %v, want %v", err, tt.want)
+			}
+			if f.committed() != tt.commits {
+				t.Errorf("committed %d times, want %d", f.committed(), tt.commits)
+			}
+		})
+	}
+}
+
+func TestUpdateItemQtyItemNotInCart(t *testing.T) {
+	s, f, _ := newCartTest(t)
+	f.answer("GetActiveCartID", row(testCartID))
+	f.answer("GetCartItem", noRows())
+
+	if err := s.UpdateItemQty(context.Background(), UserCart(7), 5, 3); err != ErrItemNotFound {
+		t.Errorf("UpdateItemQty = %v, want ErrItemNotFound", err)
+	}
+}
+
+func TestDeleteItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		deleted int64
+		want    error
+		commits int
+	}{
+		{"item deleted", 1, nil, 1},
+		{"no row deleted", 0, ErrItemNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, f, _ := newCartTest(t)
+			f.answer("GetActiveCartID", row(testCartID))
+			f.answer("DeleteReservationForCartItem", affected(tt.deleted))
+			f.answer("DeleteCartItemInCart", affected(tt.deleted))
+
+			if err := s.DeleteItem(context.Background(), UserCart(7), 5); err != tt.want {
+				t.Fatalf("DeleteItem = %v, want %v", err, tt.want)
+			}
+			if f.committed() != tt.commits {
+				t.Errorf("committed %d times, want %d", f.committed(), tt.commits)
+			}
+		})
+	}
+}
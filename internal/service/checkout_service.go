@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+var (
+	ErrCartEmpty               = errors.New("cart_empty")
+	ErrShippingAddressRequired = errors.New("shipping_address_required")
+	ErrAddressAmbiguous        = errors.New("address_ambiguous")
+)
+
+const (
+	AddressShipping = "shipping"
+	AddressBilling  = "billing"
+)
+
+const (
+	LineProductInactive   = "product_inactive"
+	LineInsufficientStock = "insufficient_stock"
+)
+
+// CheckoutLineError describes why a single cart line could not be ordered.
+type CheckoutLineError struct {
+	ProductID int64  `json:"product_id"`
+	Reason    string `json:"reason"`
+	Requested int32  `json:"requested"`
+	Available int32  `json:"available"`
+}
+
+// CheckoutError is returned when one or more cart lines cannot be fulfilled.
+// Nothing is written when it is returned.
+type CheckoutError struct {
+	Lines []CheckoutLineError
+}
+
+func (e *CheckoutError) Error() string { return "checkout_unavailable" }
+
+// CheckoutAddressError says which of the order's addresses could not be
+// used and why.
+type CheckoutAddressError struct {
+	Address string
+	Err     error
+}
+
+func (e *CheckoutAddressError) Error() string { return e.Err.Error() }
+
+func (e *CheckoutAddressError) Unwrap() error { return e.Err }
+
+// CheckoutInput picks the order's addresses. Each one is either an entry in
+// the user's address book or given in full; when neither is set the user's
+// default is used, and billing falls back to the shipping address.
+type CheckoutInput struct {
+	ShippingAddressID int64
+	ShippingAddress   *PostalAddress
+	BillingAddressID  int64
+	BillingAddress    *PostalAddress
+}
+
+type CheckoutService struct {
+	q  *sqlc.Queries
+	db *sql.DB
+}
+
+func NewCheckoutService(db *sql.DB, q *sqlc.Queries) *CheckoutService {
+	return &CheckoutService{db: db, q: q}
+}
+
+// Checkout turns the user's active cart into an order. Cart lines and their
+// products are locked for the duration of the transaction, so stock checks
+// and decrements cannot race with a concurrent checkout. The order keeps a
+// copy of its addresses, so later edits to the address book don't change it.
+func (s *CheckoutService) Checkout(ctx context.Context, userID int64, in CheckoutInput) (*Order, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	cartID, err := qtx.GetActiveCartID(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrCartEmpty
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := qtx.LockCartItemsForCheckout(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	shipping, err := checkoutAddress(ctx, qtx, userID, AddressShipping, in.ShippingAddressID, in.ShippingAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	billing, err := checkoutAddress(ctx, qtx, userID, AddressBilling, in.BillingAddressID, in.BillingAddress, shipping)
+	if err != nil {
+		return nil, err
+	}
+
+	var lineErrs []CheckoutLineError
+	items := make([]OrderItem, 0, len(rows))
+	lines := make([]Money, 0, len(rows))
+
+	for _, r := range rows {
+		switch {
+		case !r.IsActive:
+			lineErrs = append(lineErrs, CheckoutLineError{
+				ProductID: r.ProductID,
+				Reason:    LineProductInactive,
+				Requested: r.Qty,
+			})
+			continue
+		case r.Stock-r.ReservedElsewhere < r.Qty:
+			lineErrs = append(lineErrs, CheckoutLineError{
+				ProductID: r.ProductID,
+				Reason:    LineInsufficientStock,
+				Requested: r.Qty,
+				Available: max(r.Stock-r.ReservedElsewhere, 0),
+			})
+			continue
+		}
+
+		unit := NewMoney(r.PriceCents, r.Currency)
+		line := unit.Mul(r.Qty)
+		items = append(items, OrderItem{
+			ProductID: r.ProductID,
+			Name:      r.Name,
+			Qty:       r.Qty,
+			UnitPrice: unit,
+			LineTotal: line,
+		})
+		lines = append(lines, line)
+	}
+	if len(lineErrs) > 0 {
+		return nil, &CheckoutError{Lines: lineErrs}
+	}
+
+	total, err := sumMoney(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := qtx.CreateOrder(ctx, sqlc.CreateOrderParams{
+		UserID:     userID,
+		TotalCents: total.Amount,
+		Currency:   total.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := qtx.InsertOrderStatusHistory(ctx, sqlc.InsertOrderStatusHistoryParams{
+		OrderID:   o.ID,
+		ToStatus:  o.Status,
+		ChangedBy: sql.NullInt64{Int64: userID, Valid: true},
+		Reason:    "checkout",
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := createOrderAddress(ctx, qtx, o.ID, AddressShipping, shipping); err != nil {
+		return nil, err
+	}
+	if err := createOrderAddress(ctx, qtx, o.ID, AddressBilling, billing); err != nil {
+		return nil, err
+	}
+
+	for _, it := range items {
+		n, err := qtx.DecrementProductStock(ctx, sqlc.DecrementProductStockParams{
+			ID:    it.ProductID,
+			Stock: it.Qty,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, &CheckoutError{Lines: []CheckoutLineError{{
+				ProductID: it.ProductID,
+				Reason:    LineInsufficientStock,
+				Requested: it.Qty,
+			}}}
+		}
+
+		if err := qtx.CreateOrderItem(ctx, sqlc.CreateOrderItemParams{
+			OrderID:        o.ID,
+			ProductID:      it.ProductID,
+			UnitPriceCents: it.UnitPrice.Amount,
+			Qty:            it.Qty,
+			LineTotalCents: it.LineTotal.Amount,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := qtx.MarkCartCheckedOut(ctx, cartID); err != nil {
+		return nil, err
+	}
+	if err := qtx.ClearCartItems(ctx, cartID); err != nil {
+		return nil, err
+	}
+	if err := qtx.DeleteCartReservations(ctx, cartID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &Order{
+		ID:              o.ID,
+		Status:          o.Status,
+		Total:           NewMoney(o.TotalCents, o.Currency),
+		CreatedAt:       o.CreatedAt,
+		Items:           items,
+		ShippingAddress: shipping,
+		BillingAddress:  billing,
+	}, nil
+}
+
+// checkoutAddress resolves one of the order's addresses: the saved address
+// id, the address given in full, the user's default, or fallback, in that
+// order. Saved addresses are checked again, as the rules for their country
+// may have changed since they were stored.
+func checkoutAddress(ctx context.Context, qtx *sqlc.Queries, userID int64, kind string, id int64, given, fallback *PostalAddress) (*PostalAddress, error) {
+	if id != 0 && given != nil {
+		return nil, &CheckoutAddressError{Address: kind, Err: ErrAddressAmbiguous}
+	}
+
+	var a PostalAddress
+	switch {
+	case given != nil:
+		a = *given
+	case id != 0:
+		saved, err := qtx.GetAddress(ctx, sqlc.GetAddressParams{ID: id, UserID: userID})
+		if err == sql.ErrNoRows {
+			return nil, &CheckoutAddressError{Address: kind, Err: ErrAddressNotFound}
+		}
+		if err != nil {
+			return nil, err
+		}
+		a = addressView(saved).PostalAddress
+	default:
+		get := qtx.GetDefaultShippingAddress
+		if kind == AddressBilling {
+			get = qtx.GetDefaultBillingAddress
+		}
+		saved, err := get(ctx, userID)
+		if err == sql.ErrNoRows {
+			if fallback != nil {
+				return fallback, nil
+			}
+			return nil, ErrShippingAddressRequired
+		}
+		if err != nil {
+			return nil, err
+		}
+		a = addressView(saved).PostalAddress
+	}
+
+	if err := a.normalize(); err != nil {
+		return nil, &CheckoutAddressError{Address: kind, Err: err}
+	}
+	return &a, nil
+}
+
+func createOrderAddress(ctx context.Context, qtx *sqlc.Queries, orderID int64, kind string, a *PostalAddress) error {
+	return qtx.CreateOrderAddress(ctx, sqlc.CreateOrderAddressParams{
+		OrderID:    orderID,
+		Kind:       kind,
+		FullName:   a.FullName,
+		Company:    a.Company,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+	})
+}
@@ -0,0 +1,69 @@
+package service
+
+import "regexp"
+
+// countryRules describes what a valid address looks like in one country.
+type countryRules struct {
+	// postal matches a normalised (upper-case, single-spaced) postal code.
+	postal *regexp.Regexp
+	// postalOptional countries accept an address without a postal code.
+	postalOptional bool
+	// regions, when set, is the list of accepted region codes and makes the
+	// region required.
+	regions map[string]bool
+}
+
+// shippingCountries are the ISO 3166-1 alpha-2 codes we deliver to.
+var shippingCountries = map[string]countryRules{
+	"AT": {postal: regexp.MustCompile(`^\d{4}$`)},
+	"BE": {postal: regexp.MustCompile(`^\d{4}$`)},
+	"CA": {postal: regexp.MustCompile(`^[A-Z]\d[A-Z] \d[A-Z]\d$`), regions: caProvinces},
+	"CH": {postal: regexp.MustCompile(`^\d{4}$`)},
+	"CZ": {postal: regexp.MustCompile(`^\d{3} \d{2}$`)},
+	"DE": {postal: regexp.MustCompile(`^\d{5}$`)},
+	"DK": {postal: regexp.MustCompile(`^\d{4}$`)},
+	"ES": {postal: regexp.MustCompile(`^\d{5}$`)},
+	"FI": {postal: regexp.MustCompile(`^\d{5}$`)},
+	"FR": {postal: regexp.MustCompile(`^\d{5}$`)},
+	"GB": {postal: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}$`)},
+	"HU": {postal: regexp.MustCompile(`^\d{4}$`)},
+	"IE": {postal: regexp.MustCompile(`^[A-Z]\d[\dW] [A-Z\d]{4}$`), postalOptional: true},
+	"IT": {postal: regexp.MustCompile(`^\d{5}$`)},
+	"LU": {postal: regexp.MustCompile(`^\d{4}$`)},
+	"NL": {postal: regexp.MustCompile(`^\d{4} [A-Z]{2}$`)},
+	"NO": {postal: regexp.MustCompile(`^\d{4}$`)},
+	"PL": {postal: regexp.MustCompile(`^\d{2}-\d{3}$`)},
+	"PT": {postal: regexp.MustCompile(`^\d{4}-\d{3}$`)},
+	"RO": {postal: regexp.MustCompile(`^\d{6}$`)},
+	"SE": {postal: regexp.MustCompile(`^\d{3} \d{2}$`)},
+	"US": {postal: regexp.MustCompile(`^\d{5}(-\d{4})?$`), regions: usStates},
+}
+
+// postalSpacing inserts the separator some countries write inside postal
+// codes, so "sw1a1aa" and "SW1A 1AA" are stored the same way. The inward
+// part of each code is always the last few characters.
+var postalSpacing = map[string]int{
+	"CA": 3,
+	"CZ": 2,
+	"GB": 3,
+	"IE": 4,
+	"NL": 2,
+	"SE": 2,
+}
+
+var usStates = codeSet(
+	"AL", "AK", "AZ", "AR", "CA", "CO", "CT", "DE", "DC", "FL", "GA", "HI", "ID", "IL", "IN", "IA", "KS",
+	"KY", "LA", "ME", "MD", "MA", "MI", "MN", "MS", "MO", "MT", "NE", "NV", "NH", "NJ", "NM", "NY", "NC",
+	"ND", "OH", "OK", "OR", "PA", "RI", "SC", "SD", "TN", "TX", "UT", "VT", "VA", "WA", "WV", "WI", "WY",
+	"AS", "GU", "MP", "PR", "VI", "AA", "AE", "AP",
+)
+
+var caProvinces = codeSet("AB", "BC", "MB", "NB", "NL", "NS", "NT", "NU", "ON", "PE", "QC", "SK", "YT")
+
+func codeSet(codes ...string) map[string]bool {
+	m := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		m[c] = true
+	}
+	return m
+}
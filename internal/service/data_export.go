@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+// DataExport is everything stored about a user, as handed to them on
+// request (GDPR art. 15 and 20).
+type DataExport struct {
+	ExportedAt     time.Time        `json:"exported_at"`
+	Profile        ExportProfile    `json:"profile"`
+	Addresses      []Address        `json:"addresses"`
+	LinkedAccounts []ExportIdentity `json:"linked_accounts"`
+	Carts          []ExportCart     `json:"carts"`
+	Orders         []ExportOrder    `json:"orders"`
+}
+
+type ExportProfile struct {
+	ID                  int64      `json:"id"`
+	Email               string     `json:"email"`
+	FirstName           string     `json:"first_name"`
+	LastName            string     `json:"last_name"`
+	Phone               string     `json:"phone"`
+	EmailVerified       bool       `json:"email_verified"`
+	TwoFactorEnabled    bool       `json:"two_factor_enabled"`
+	Roles               []string   `json:"roles"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+}
+
+type ExportIdentity struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportCart struct {
+	ID        int64            `json:"id"`
+	Status    string           `json:"status"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Items     []ExportCartItem `json:"items"`
+}
+
+type ExportCartItem struct {
+	ProductID int64     `json:"product_id"`
+	Name      string    `json:"name"`
+	Qty       int32     `json:"qty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ExportOrder struct {
+	Order
+	History []OrderStatusChange `json:"history"`
+}
+
+type DataExportService struct {
+	q *sqlc.Queries
+}
+
+func NewDataExportService(q *sqlc.Queries) *DataExportService {
+	return &DataExportService{q: q}
+}
+
+func (s *DataExportService) Export(ctx context.Context, userID int64) (*DataExport, error) {
+	u, err := s.q.GetUserForExport(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := &DataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: ExportProfile{
+			ID:            u.ID,
+			Email:         u.Email,
+			FirstName:     u.FirstName,
+			LastName:      u.LastName,
+			Phone:         u.Phone,
+			EmailVerified: u.EmailVerifiedAt.Valid,
+			CreatedAt:     u.CreatedAt,
+			UpdatedAt:     u.UpdatedAt,
+		},
+	}
+	if u.DeletionRequestedAt.Valid {
+		out.Profile.DeletionRequestedAt = &u.DeletionRequestedAt.Time
+	}
+
+	roles, err := s.q.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out.Profile.Roles = append([]string{}, roles...)
+
+	t, err := s.q.GetUserTotp(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	out.Profile.TwoFactorEnabled = err == nil && t.ConfirmedAt.Valid
+
+	if out.Addresses, err = s.addresses(ctx, userID); err != nil {
+		return nil, err
+	}
+	if out.LinkedAccounts, err = s.identities(ctx, userID); err != nil {
+		return nil, err
+	}
+	if out.Carts, err = s.carts(ctx, userID); err != nil {
+		return nil, err
+	}
+	if out.Orders, err = s.orders(ctx, userID); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *DataExportService) addresses(ctx context.Context, userID int64) ([]Address, error) {
+	rows, err := s.q.ListAddresses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Address, 0, len(rows))
+	for _, a := range rows {
+		out = append(out, addressView(a))
+	}
+	return out, nil
+}
+
+func (s *DataExportService) identities(ctx context.Context, userID int64) ([]ExportIdentity, error) {
+	rows, err := s.q.ListUserIdentities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ExportIdentity, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, ExportIdentity{Provider: r.Provider, Email: r.Email, CreatedAt: r.CreatedAt})
+	}
+	return out, nil
+}
+
+func (s *DataExportService) carts(ctx context.Context, userID int64) ([]ExportCart, error) {
+	carts, err := s.q.ListCartsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.q.ListCartItemsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byCart := map[int64][]ExportCartItem{}
+	for _, i := range items {
+		byCart[i.CartID] = append(byCart[i.CartID], ExportCartItem{
+			ProductID: i.ProductID,
+			Name:      i.Name,
+			Qty:       i.Qty,
+			CreatedAt: i.CreatedAt,
+			UpdatedAt: i.UpdatedAt,
+		})
+	}
+
+	out := make([]ExportCart, 0, len(carts))
+	for _, c := range carts {
+		out = append(out, ExportCart{
+			ID:        c.ID,
+			Status:    c.Status,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+			Items:     append([]ExportCartItem{}, byCart[c.ID]...),
+		})
+	}
+	return out, nil
+}
+
+func (s *DataExportService) orders(ctx context.Context, userID int64) ([]ExportOrder, error) {
+	orders, err := s.q.ListAllOrdersForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.q.ListOrderItemsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.q.ListOrderStatusHistoryForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := s.q.ListOrderAddressesForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := make(map[int64]string, len(orders))
+	for _, o := range orders {
+		currency[o.ID] = o.Currency
+	}
+	itemsByOrder := map[int64][]OrderItem{}
+	for _, i := range items {
+		cur := currency[i.OrderID]
+		itemsByOrder[i.OrderID] = append(itemsByOrder[i.OrderID], OrderItem{
+			ProductID: i.ProductID,
+			Name:      i.Name,
+			Qty:       i.Qty,
+			UnitPrice: NewMoney(i.UnitPriceCents, cur),
+			LineTotal: NewMoney(i.LineTotalCents, cur),
+		})
+	}
+	historyByOrder := map[int64][]OrderStatusChange{}
+	for _, h := range history {
+		// who on staff made a change is not the customer's data
+		historyByOrder[h.OrderID] = append(historyByOrder[h.OrderID], OrderStatusChange{
+			FromStatus: h.FromStatus.String,
+			ToStatus:   h.ToStatus,
+			Reason:     h.Reason,
+			CreatedAt:  h.CreatedAt,
+		})
+	}
+
+	addrsByOrder := map[int64][]sqlc.OrderAddress{}
+	for _, a := range addrs {
+		addrsByOrder[a.OrderID] = append(addrsByOrder[a.OrderID], a)
+	}
+
+	out := make([]ExportOrder, 0, len(orders))
+	for _, o := range orders {
+		e := ExportOrder{
+			Order: Order{
+				ID:        o.ID,
+				Status:    o.Status,
+				Total:     NewMoney(o.TotalCents, o.Currency),
+				CreatedAt: o.CreatedAt,
+				Items:     itemsByOrder[o.ID],
+			},
+			History: append([]OrderStatusChange{}, historyByOrder[o.ID]...),
+		}
+		e.setAddresses(addrsByOrder[o.ID])
+		out = append(out, e)
+	}
+	return out, nil
+}
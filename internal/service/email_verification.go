@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/mail"
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+var (
+	ErrVerifyTokenInvalid   = errors.New("invalid_verification_token")
+	ErrEmailAlreadyVerified = errors.New("email_already_verified")
+)
+
+// RateLimitError means the caller has to wait RetryAfter before trying again.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string { return "too_many_requests" }
+
+// VerifyEmail marks the address a token was mailed to as verified. Each
+// token works once.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return ErrVerifyTokenInvalid
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	userID, err := qtx.ConsumeEmailVerificationToken(ctx, hashToken(token))
+	if err == sql.ErrNoRows {
+		return ErrVerifyTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+	if err := qtx.MarkEmailVerified(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ResendVerification mails a fresh verification link, at most once every
+// VerificationResendInterval per user.
+func (s *AuthService) ResendVerification(ctx context.Context, userID int64) error {
+	u, err := s.q.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerifiedAt.Valid {
+		return ErrEmailAlreadyVerified
+	}
+
+	last, err := s.q.GetLastEmailVerificationSentAt(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		if wait := last.Add(s.opts.VerificationResendInterval).Sub(s.now()); wait > 0 {
+			return &RateLimitError{RetryAfter: wait}
+		}
+	}
+
+	return s.sendVerification(ctx, userID, u.Email)
+}
+
+// IsEmailVerified reports whether the user has confirmed their address.
+func (s *AuthService) IsEmailVerified(ctx context.Context, userID int64) (bool, error) {
+	return s.q.IsEmailVerified(ctx, userID)
+}
+
+// sendVerification replaces any outstanding verification token of the user
+// and mails the new one to email.
+func (s *AuthService) sendVerification(ctx context.Context, userID int64, email string) error {
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	if err := qtx.InvalidateEmailVerificationTokens(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.CreateEmailVerificationToken(ctx, sqlc.CreateEmailVerificationTokenParams{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: s.now().Add(s.opts.VerificationTTL),
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	link := s.opts.AppURL + "/verify-email?token=" + url.QueryEscape(token)
+	s.sendMail(ctx, mail.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Follow this link within %s to verify your email address:\n\n%s\n",
+			s.opts.VerificationTTL, link),
+	})
+	return nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDB is a database/sql driver that answers sqlc queries by name, so
+// service logic can be tested without Postgres. Tests register a handler for
+// every query they expect; any other query fails the test.
+type fakeDB struct {
+	t        *testing.T
+	mu       sync.Mutex
+	handlers map[string]func(args []driver.Value) (fakeResult, error)
+	calls    map[string]int
+	commits  int
+}
+
+// fakeResult is a query's answer: rows for queries, affected for execs.
+type fakeResult struct {
+	rows     [][]driver.Value
+	affected int64
+}
+
+func row(vals ...driver.Value) fakeResult { return fakeResult{rows: [][]driver.Value{vals}} }
+
+func rows(rs ...[]driver.Value) fakeResult { return fakeResult{rows: rs} }
+
+func noRows() fakeResult { return fakeResult{} }
+
+func affected(n int64) fakeResult { return fakeResult{affected: n} }
+
+func newFakeDB(t *testing.T) (*fakeDB, *sql.DB) {
+	t.Helper()
+	f := &fakeDB{
+		t:        t,
+		handlers: map[string]func([]driver.Value) (fakeResult, error){},
+		calls:    map[string]int{},
+	}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() })
+	return f, db
+}
+
+// on answers the query called name with h.
+func (f *fakeDB) on(name string, h func(args []driver.Value) (fakeResult, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[name] = h
+}
+
+// answer always answers the query called name with res.
+func (f *fakeDB) answer(name string, res fakeResult) {
+	f.on(name, func([]driver.Value) (fakeResult, error) { return res, nil })
+}
+
+// called is how many times the query called name ran.
+func (f *fakeDB) called(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[name]
+}
+
+func (f *fakeDB) committed() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.commits
+}
+
+func (f *fakeDB) run(query string, named []driver.NamedValue) (fakeResult, error) {
+	name := queryName(query)
+	args := make([]driver.Value, len(named))
+	for i, nv := range named {
+		args[i] = nv.Value
+	}
+
+	f.mu.Lock()
+	h, ok := f.handlers[name]
+	f.calls[name]++
+	f.mu.Unlock()
+
+	if !ok {
+		f.t.Errorf("unexpected query %s%v", name, args)
+		return fakeResult{}, fmt.Errorf("fakedb: no handler for %s", name)
+	}
+	return h(args)
+}
+
+// queryName reads the name sqlc puts on the first line of every query.
+func queryName(query string) string {
+	line, _, _ := strings.Cut(query, "\n")
+	if f := strings.Fields(line); len(f) >= 3 && f[1] == "name:" {
+		return f[2]
+	}
+	return line
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+
+func (f *fakeDB) Driver() driver.Driver { return fakeDriver{f} }
+
+type fakeDriver struct{ f *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return fakeConn(d), nil }
+
+type fakeConn struct{ f *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepared statements are not supported")
+}
+
+func (c fakeConn) Close() error { return nil }
+
+func (c fakeConn) Begin() (driver.Tx, error) { return fakeTx(c), nil }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res, err := c.f.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: res.rows}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := c.f.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(res.affected), nil
+}
+
+type fakeTx struct{ f *fakeDB }
+
+func (tx fakeTx) Commit() error {
+	tx.f.mu.Lock()
+	defer tx.f.mu.Unlock()
+	tx.f.commits++
+	return nil
+}
+
+func (tx fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	cols := make([]string, len(r.rows[0]))
+	for i := range cols {
+		cols[i] = fmt.Sprintf("c%d", i)
+	}
+	return cols
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
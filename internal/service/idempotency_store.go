@@ -0,0 +1,145 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+// IdempotencyRecord is the stored outcome of a request made with an
+// Idempotency-Key. StatusCode is zero while the request is still running.
+type IdempotencyRecord struct {
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	// Header holds the other response headers a replay must repeat.
+	Header map[string][]string
+	Body   []byte
+}
+
+// IdempotencyStore keeps Idempotency-Key records in Postgres. Keys older
+// than ttl are treated as unused.
+type IdempotencyStore struct {
+	q   *sqlc.Queries
+	ttl time.Duration
+}
+
+func NewIdempotencyStore(q *sqlc.Queries, ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{q: q, ttl: ttl}
+}
+
+func (s *IdempotencyStore) Begin(ctx context.Context, scope, key, requestHash string) (*IdempotencyRecord, bool, error) {
+	if err := s.q.DeleteStaleIdempotencyKey(ctx, sqlc.DeleteStaleIdempotencyKeyParams{
+		Scope:      scope,
+		Key:        key,
+		TtlSeconds: s.ttl.Seconds(),
+	}); err != nil {
+		return nil, false, err
+	}
+
+	insert := sqlc.InsertIdempotencyKeyParams{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+	}
+	n, err := s.q.InsertIdempotencyKey(ctx, insert)
+	if err != nil {
+		return nil, false, err
+	}
+	if n == 1 {
+		return nil, true, nil
+	}
+
+	row, err := s.q.GetIdempotencyKey(ctx, sqlc.GetIdempotencyKeyParams{
+		Scope: scope,
+		Key:   key,
+	})
+	if err == sql.ErrNoRows {
+		// the holder released the key between our insert and read; try
+		// once more to claim it
+		n, err = s.q.InsertIdempotencyKey(ctx, insert)
+		if err != nil {
+			return nil, false, err
+		}
+		if n == 1 {
+			return nil, true, nil
+		}
+		row, err = s.q.GetIdempotencyKey(ctx, sqlc.GetIdempotencyKeyParams{
+			Scope: scope,
+			Key:   key,
+		})
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	header, err := parseHeader(row.ResponseHeaders)
+	if err != nil {
+		return nil, false, err
+	}
+	return &IdempotencyRecord{
+		RequestHash: row.RequestHash,
+		StatusCode:  int(row.StatusCode.Int32),
+		ContentType: row.ContentType,
+		Header:      header,
+		Body:        row.ResponseBody,
+	}, false, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, scope, key string, rec IdempotencyRecord) error {
+	return s.q.CompleteIdempotencyKey(ctx, sqlc.CompleteIdempotencyKeyParams{
+		Scope:           scope,
+		Key:             key,
+		StatusCode:      sql.NullInt32{Int32: int32(rec.StatusCode), Valid: true},
+		ContentType:     rec.ContentType,
+		ResponseHeaders: formatHeader(rec.Header),
+		ResponseBody:    rec.Body,
+	})
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, scope, key string) error {
+	return s.q.DeleteIdempotencyKey(ctx, sqlc.DeleteIdempotencyKeyParams{
+		Scope: scope,
+		Key:   key,
+	})
+}
+
+// Purge deletes expired keys; it is meant to be run by a Sweeper.
+func (s *IdempotencyStore) Purge(ctx context.Context) (int64, error) {
+	return s.q.DeleteExpiredIdempotencyKeys(ctx, s.ttl.Seconds())
+}
+
+// formatHeader writes h as "Name: value" lines, in the form parseHeader
+// reads back.
+func formatHeader(h map[string][]string) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		for _, v := range h[name] {
+			fmt.Fprintf(&b, "%s: %s\r\n", name, v)
+		}
+	}
+	return b.String()
+}
+
+func parseHeader(s string) (map[string][]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	h, err := textproto.NewReader(bufio.NewReader(strings.NewReader(s + "\r\n"))).ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("stored idempotency headers: %w", err)
+	}
+	return h, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+func newIdempotencyTest(t *testing.T) (*IdempotencyStore, *fakeDB) {
+	t.Helper()
+	f, db := newFakeDB(t)
+	f.answer("DeleteStaleIdempotencyKey", affected(0))
+	return NewIdempotencyStore(sqlc.New(db), 24*time.Hour), f
+}
+
+func TestIdempotencyStoreKeepsHeaders(t *testing.T) {
+	s, f := newIdempotencyTest(t)
+	ctx := context.Background()
+
+	var stored driver.Value
+	f.on("CompleteIdempotencyKey", func(args []driver.Value) (fakeResult, error) {
+		stored = args[4]
+		return affected(1), nil
+	})
+	header := map[string][]string{
+		"X-Cart-Token": {"Jx2m0k"},
+		"Set-Cookie":   {"cart_token=Jx2m0k; Path=/; HttpOnly", "other=1"},
+	}
+	err := s.Complete(ctx, "ip:x", "k", IdempotencyRecord{RequestHash: "h", StatusCode: 201, Header: header})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	f.answer("InsertIdempotencyKey", affected(0))
+	f.answer("GetIdempotencyKey", row("h", int64(201), "application/json", stored, []byte("{}")))
+	rec, claimed, err := s.Begin(ctx, "ip:x", "k", "h")
+	if err != nil || claimed {
+		t.Fatalf("Begin = %v, %v, want the stored record", claimed, err)
+	}
+	if !reflect.DeepEqual(rec.Header, header) {
+		t.Errorf("Header = %v, want %v", rec.Header, header)
+	}
+}
+
+func TestIdempotencyStoreBeginAfterRelease(t *testing.T) {
+	s, f := newIdempotencyTest(t)
+
+	// the first insert loses to a holder that releases the key before we
+	// read it back, so the retry claims it
+	inserted := []int64{0, 1}
+	f.on("InsertIdempotencyKey", func([]driver.Value) (fakeResult, error) {
+		n := inserted[0]
+		inserted = inserted[1:]
+		return affected(n), nil
+	})
+	f.answer("GetIdempotencyKey", noRows())
+
+	rec, claimed, err := s.Begin(context.Background(), "user:7", "k", "h")
+	if err != nil || !claimed || rec != nil {
+		t.Fatalf("Begin = %v, %v, %v, want the key claimed", rec, claimed, err)
+	}
+	if n := f.called("InsertIdempotencyKey"); n != 2 {
+		t.Errorf("InsertIdempotencyKey ran %d times, want 2", n)
+	}
+}
+
+func TestIdempotencyStoreBeginRetriesOnce(t *testing.T) {
+	s, f := newIdempotencyTest(t)
+	f.answer("InsertIdempotencyKey", affected(0))
+	// someone claims the key again before our second insert
+	reads := 0
+	f.on("GetIdempotencyKey", func([]driver.Value) (fakeResult, error) {
+		reads++
+		if reads == 1 {
+			return noRows(), nil
+		}
+		return row("h", nil, "", "", nil), nil
+	})
+
+	rec, claimed, err := s.Begin(context.Background(), "user:7", "k", "h")
+	if err != nil || claimed || rec == nil || rec.StatusCode != 0 {
+		t.Fatalf("Begin = %+v, %v, %v, want the other request's record in progress", rec, claimed, err)
+	}
+}
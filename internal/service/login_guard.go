@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// AccountLockedError means too many failed logins locked the account.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string { return "account_locked" }
+
+// AttemptState is the failure history of one key (an account or a client IP).
+type AttemptState struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// AttemptStore keeps failed-login counters. Implementations must make
+// RecordFailure atomic so concurrent failures are all counted.
+type AttemptStore interface {
+	Get(ctx context.Context, key string) (AttemptState, error)
+	// RecordFailure counts a failure at now and returns the new state. A
+	// previous failure older than window starts the count over.
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (AttemptState, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	// Purge forgets keys whose last failure and lock are both before before.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AttemptPolicy decides how failures translate into waiting.
+type AttemptPolicy struct {
+	// FreeAttempts failures are allowed before backoff starts.
+	FreeAttempts int
+	// BaseDelay is the first backoff; it doubles with every further failure
+	// up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockAfter failures lock the key for LockFor. Zero never locks.
+	LockAfter int
+	LockFor   time.Duration
+	// Window is how long a failure is remembered.
+	Window time.Duration
+}
+
+// delay is how long to wait after the last of failures before trying again.
+func (p AttemptPolicy) delay(failures int) time.Duration {
+	if failures < p.FreeAttempts {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := p.FreeAttempts; i < failures && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDelay)
+}
+
+// DefaultAccountPolicy backs off after 3 failures and locks the account for
+// 15 minutes after 10.
+var DefaultAccountPolicy = AttemptPolicy{
+	FreeAttempts: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     5 * time.Minute,
+	LockAfter:    10,
+	LockFor:      15 * time.Minute,
+	Window:       time.Hour,
+}
+
+// DefaultIPPolicy is looser, since many users can share an address, and
+// never locks.
+var DefaultIPPolicy = AttemptPolicy{
+	FreeAttempts: 20,
+	BaseDelay:    time.Second,
+	MaxDelay:     5 * time.Minute,
+	Window:       time.Hour,
+}
+
+// LoginGuard tracks failed logins per account and per client IP, and tells
+// Login when an attempt has to be refused.
+type LoginGuard struct {
+	store   AttemptStore
+	account AttemptPolicy
+	ip      AttemptPolicy
+	now     func() time.Time
+}
+
+// NewLoginGuard builds a guard reading time from now; nil means time.Now.
+// Pass the AuthService's clock so both agree.
+func NewLoginGuard(store AttemptStore, account, ip AttemptPolicy, now func() time.Time) *LoginGuard {
+	if now == nil {
+		now = time.Now
+	}
+	return &LoginGuard{store: store, account: account, ip: ip, now: now}
+}
+
+func accountKey(email string) string { return "account:" + email }
+func ipKey(ip string) string         { return "ip:" + ip }
+
+// Check returns an *AccountLockedError or *RateLimitError if an attempt for
+// email from ip must not be tried now.
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) error {
+	if err := g.check(ctx, accountKey(email), g.account); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return g.check(ctx, ipKey(ip), g.ip)
+}
+
+func (g *LoginGuard) check(ctx context.Context, key string, p AttemptPolicy) error {
+	st, err := g.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	now := g.now()
+
+	if now.Before(st.LockedUntil) {
+		return &AccountLockedError{RetryAfter: st.LockedUntil.Sub(now)}
+	}
+	if st.Failures == 0 || now.Sub(st.LastFailure) > p.Window {
+		return nil
+	}
+	if wait := st.LastFailure.Add(p.delay(st.Failures)).Sub(now); wait > 0 {
+		return &RateLimitError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Fail records a failed attempt, locking the account once it reaches the
+// policy's limit.
+func (g *LoginGuard) Fail(ctx context.Context, email, ip string) error {
+	if err := g.fail(ctx, accountKey(email), g.account); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return g.fail(ctx, ipKey(ip), g.ip)
+}
+
+func (g *LoginGuard) fail(ctx context.Context, key string, p AttemptPolicy) error {
+	now := g.now()
+	st, err := g.store.RecordFailure(ctx, key, now, p.Window)
+	if err != nil {
+		return err
+	}
+	if p.LockAfter > 0 && st.Failures >= p.LockAfter {
+		return g.store.Lock(ctx, key, now.Add(p.LockFor))
+	}
+	return nil
+}
+
+// Succeed clears the account's failures. The IP's are kept, so one valid
+// account can't be used to reset the counter for guessing others.
+func (g *LoginGuard) Succeed(ctx context.Context, email string) error {
+	return g.store.Reset(ctx, accountKey(email))
+}
+
+// Unlock lifts a lockout and clears the account's failures.
+func (g *LoginGuard) Unlock(ctx context.Context, email string) error {
+	return g.store.Reset(ctx, accountKey(email))
+}
+
+// Purge drops counters nothing remembers anymore; it is meant to be run by
+// a Sweeper.
+func (g *LoginGuard) Purge(ctx context.Context) (int64, error) {
+	return g.store.Purge(ctx, g.now().Add(-max(g.account.Window, g.ip.Window)))
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+	"github.com/angelchiav/go-ecommerce/internal/totp"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("mfa_already_enabled")
+	ErrMFANotEnabled     = errors.New("mfa_not_enabled")
+	ErrMFANotPending     = errors.New("mfa_enrolment_not_started")
+	ErrMFACodeInvalid    = errors.New("invalid_mfa_code")
+	ErrMFATokenInvalid   = errors.New("invalid_mfa_token")
+)
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+	// totpSkew accepts codes from one step either side of now.
+	totpSkew          = 1
+	recoveryCodeCount = 10
+)
+
+// LoginResult is the outcome of a password login: either a token pair, or,
+// for accounts with two-factor authentication, a challenge to be completed
+// with VerifyMFA.
+type LoginResult struct {
+	*TokenPair
+	MFARequired  bool       `json:"mfa_required,omitempty"`
+	MFAToken     string     `json:"mfa_token,omitempty"`
+	MFAExpiresAt *time.Time `json:"mfa_expires_at,omitempty"`
+}
+
+// TOTPConfirmation is what turning two-factor authentication on returns:
+// the recovery codes, and a token pair replacing the sessions it ended.
+type TOTPConfirmation struct {
+	*TokenPair
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TOTPEnrolment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// mfaEnabled reports whether the user has confirmed a TOTP secret.
+func (s *AuthService) mfaEnabled(ctx context.Context, q *sqlc.Queries, userID int64) (bool, error) {
+	t, err := q.GetUserTotp(ctx, userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return t.ConfirmedAt.Valid, nil
+}
+
+// startMFA issues the short-lived challenge a password login hands out
+// instead of tokens.
+func (s *AuthService) startMFA(ctx context.Context, userID int64) (*LoginResult, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	exp := s.now().Add(mfaChallengeTTL)
+	if err := s.q.CreateMfaChallenge(ctx, sqlc.CreateMfaChallengeParams{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: exp,
+	}); err != nil {
+		return nil, err
+	}
+	return &LoginResult{MFARequired: true, MFAToken: token, MFAExpiresAt: &exp}, nil
+}
+
+// VerifyMFA completes a login with the challenge token from Login and either
+// a current TOTP code or an unused recovery code. Wrong codes count as failed
+// logins, and a challenge dies after a few of them.
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code, ip string) (*TokenPair, error) {
+	if mfaToken == "" {
+		return nil, ErrMFATokenInvalid
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	ch, err := qtx.GetMfaChallengeForUpdate(ctx, hashToken(mfaToken))
+	if err == sql.ErrNoRows {
+		return nil, ErrMFATokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ch.UsedAt.Valid || !s.now().Before(ch.ExpiresAt) || ch.Attempts >= mfaChallengeMaxAttempts {
+		return nil, ErrMFATokenInvalid
+	}
+
+	u, err := qtx.GetUserByID(ctx, ch.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.guard.Check(ctx, u.Email, ip); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.checkSecondFactor(ctx, qtx, u.ID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := qtx.IncrementMfaChallengeAttempts(ctx, ch.ID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		if err := s.guard.Fail(ctx, u.Email, ip); err != nil {
+			return nil, err
+		}
+		return nil, ErrMFACodeInvalid
+	}
+
+	if err := qtx.MarkMfaChallengeUsed(ctx, ch.ID); err != nil {
+		return nil, err
+	}
+	family, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	pair, err := s.issue(ctx, qtx, u.ID, u.TokenVersion, family)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if err := s.guard.Succeed(ctx, u.Email); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// checkSecondFactor accepts a TOTP code not used before, or an unused
+// recovery code, burning whichever matched.
+func (s *AuthService) checkSecondFactor(ctx context.Context, qtx *sqlc.Queries, userID int64, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		t, err := qtx.GetUserTotp(ctx, userID)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		step, ok := totp.Validate(t.Secret, code, s.now(), totpSkew)
+		if !ok {
+			return false, nil
+		}
+		// a code can't be replayed within its window
+		n, err := qtx.UseTotpStep(ctx, sqlc.UseTotpStepParams{
+			UserID:       userID,
+			LastUsedStep: step,
+		})
+		if err != nil {
+			return false, err
+		}
+		return n == 1, nil
+	}
+
+	n, err := qtx.UseRecoveryCode(ctx, sqlc.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: hashToken(normalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// EnrolTOTP starts two-factor enrolment with a fresh secret. It only takes
+// effect once ConfirmTOTP proves the authenticator app has it; starting over
+// replaces an unconfirmed secret.
+func (s *AuthService) EnrolTOTP(ctx context.Context, userID int64) (*TOTPEnrolment, error) {
+	enabled, err := s.mfaEnabled(ctx, s.q, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	u, err := s.q.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.q.UpsertPendingTotp(ctx, sqlc.UpsertPendingTotpParams{
+		UserID: userID,
+		Secret: secret,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrolment{
+		Secret: secret,
+		URI:    totp.URI(s.opts.MFAIssuer, u.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP turns two-factor authentication on once code shows the
+// secret from EnrolTOTP was set up, and returns the recovery codes. They are
+// only ever shown here. Sessions started without the second factor are
+// ended, as on a password change, and a new token pair returned.
+func (s *AuthService) ConfirmTOTP(ctx context.Context, userID int64, code string) (*TOTPConfirmation, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	t, err := qtx.GetUserTotp(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMFANotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.ConfirmedAt.Valid {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(t.Secret, strings.TrimSpace(code), s.now(), totpSkew)
+	if !ok {
+		return nil, ErrMFACodeInvalid
+	}
+	if err := qtx.ConfirmTotp(ctx, sqlc.ConfirmTotpParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	}); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, qtx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.endSessions(ctx, qtx, userID); err != nil {
+		return nil, err
+	}
+
+	u, err := qtx.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	family, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	pair, err := s.issue(ctx, qtx, u.ID, u.TokenVersion, family)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.revocations.forget(userID)
+	return &TOTPConfirmation{TokenPair: pair, RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP turns two-factor authentication off after checking the
+// password.
+func (s *AuthService) DisableTOTP(ctx context.Context, userID int64, password string) error {
+	if err := s.checkPassword(ctx, userID, password); err != nil {
+		return err
+	}
+	enabled, err := s.mfaEnabled(ctx, s.q, userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrMFANotEnabled
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	if err := qtx.DeleteUserTotp(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RegenerateRecoveryCodes voids the user's recovery codes and returns a new
+// set, after checking the password.
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID int64, password string) ([]string, error) {
+	if err := s.checkPassword(ctx, userID, password); err != nil {
+		return nil, err
+	}
+	enabled, err := s.mfaEnabled(ctx, s.q, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	codes, err := s.replaceRecoveryCodes(ctx, s.q.WithTx(tx), userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *AuthService) replaceRecoveryCodes(ctx context.Context, qtx *sqlc.Queries, userID int64) ([]string, error) {
+	if err := qtx.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		if err := qtx.CreateRecoveryCode(ctx, sqlc.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		}); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// newRecoveryCode returns a code like "k3m9x-p2q7w": 50 random bits, easy to
+// type.
+func newRecoveryCode() (string, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+	c := strings.ToLower(secret[:10])
+	return c[:5] + "-" + c[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
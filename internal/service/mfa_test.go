@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/jwtkeys"
+	"github.com/angelchiav/go-ecommerce/internal/mail"
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+	"github.com/angelchiav/go-ecommerce/internal/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	mfaTestEmail    = "ada@example.com"
+	mfaTestPassword = "correct horse battery"
+	mfaTestSecret   = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+)
+
+// fakeClock is a clock tests move by hand.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// mfaAccount is the state of one user with TOTP enabled, as the fake
+// database sees it.
+type mfaAccount struct {
+	passwordHash string
+	lastUsedStep int64
+	challenges   map[string]*mfaTestChallenge
+}
+
+type mfaTestChallenge struct {
+	id        int64
+	attempts  int64
+	expiresAt time.Time
+	used      bool
+}
+
+// newMFATest wires an AuthService and its LoginGuard to one fake clock and a
+// fake database holding a single account with TOTP enabled. The account
+// locks after three failures.
+func newMFATest(t *testing.T) (*AuthService, *fakeDB, *fakeClock, *mfaAccount) {
+	t.Helper()
+	f, db := newFakeDB(t)
+	q := sqlc.New(db)
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(mfaTestPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acct := &mfaAccount{passwordHash: string(hash), challenges: map[string]*mfaTestChallenge{}}
+
+	f.on("GetUserByEmail", func(args []driver.Value) (fakeResult, error) {
+		if args[0] != mfaTestEmail {
+			return noRows(), nil
+		}
+		return row(int64(1), mfaTestEmail, acct.passwordHash, int64(0)), nil
+	})
+	f.answer("GetUserByID", row(int64(1), mfaTestEmail, int64(0), clock.t))
+	f.on("GetUserTotp", func([]driver.Value) (fakeResult, error) {
+		return row(mfaTestSecret, clock.t, acct.lastUsedStep), nil
+	})
+	f.on("UseTotpStep", func(args []driver.Value) (fakeResult, error) {
+		step := args[1].(int64)
+		if step <= acct.lastUsedStep {
+			return affected(0), nil
+		}
+		acct.lastUsedStep = step
+		return affected(1), nil
+	})
+	f.on("CreateMfaChallenge", func(args []driver.Value) (fakeResult, error) {
+		acct.challenges[args[1].(string)] = &mfaTestChallenge{
+			id:        int64(len(acct.challenges) + 1),
+			expiresAt: args[2].(time.Time),
+		}
+		return affected(1), nil
+	})
+	f.on("GetMfaChallengeForUpdate", func(args []driver.Value) (fakeResult, error) {
+		ch, ok := acct.challenges[args[0].(string)]
+		if !ok {
+			return noRows(), nil
+		}
+		var usedAt driver.Value
+		if ch.used {
+			usedAt = ch.expiresAt
+		}
+		return row(ch.id, int64(1), ch.attempts, ch.expiresAt, usedAt), nil
+	})
+	f.on("IncrementMfaChallengeAttempts", func(args []driver.Value) (fakeResult, error) {
+		for _, ch := range acct.challenges {
+			if ch.id == args[0].(int64) {
+				ch.attempts++
+			}
+		}
+		return affected(1), nil
+	})
+	f.on("MarkMfaChallengeUsed", func(args []driver.Value) (fakeResult, error) {
+		for _, ch := range acct.challenges {
+			if ch.id == args[0].(int64) {
+				ch.used = true
+			}
+		}
+		return affected(1), nil
+	})
+	f.answer("CancelAccountDeletion", affected(0))
+	f.answer("ListUserPermissions", rows())
+	f.answer("CreateRefreshToken", affected(1))
+
+	guard := NewLoginGuard(NewMemoryAttemptStore(), AttemptPolicy{
+		FreeAttempts: 10,
+		LockAfter:    3,
+		LockFor:      15 * time.Minute,
+		Window:       time.Hour,
+	}, DefaultIPPolicy, clock.now)
+	svc := NewAuthService(db, q, NewRevocationStore(q), jwtkeys.NewHMAC([]byte("test-secret")),
+		mail.NewLogMailer("test@example.com"), guard, AuthOptions{
+			AccessTTL:  15 * time.Minute,
+			RefreshTTL: 24 * time.Hour,
+			Now:        clock.now,
+		})
+	return svc, f, clock, acct
+}
+
+func totpCode(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := totp.Code(mfaTestSecret, totp.Step(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// wrongCode is a well-formed code no step near at accepts.
+func wrongCode(t *testing.T, at time.Time) string {
+	t.Helper()
+	for n := 0; ; n++ {
+		code := strconv.Itoa(100000 + n)
+		if _, ok := totp.Validate(mfaTestSecret, code, at, totpSkew); !ok {
+			return code
+		}
+	}
+}
+
+func startMFALogin(t *testing.T, svc *AuthService) *LoginResult {
+	t.Helper()
+	res, err := svc.Login(context.Background(), mfaTestEmail, mfaTestPassword, "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if !res.MFARequired || res.TokenPair != nil {
+		t.Fatalf("Login = %+v, want an MFA challenge", res)
+	}
+	return res
+}
+
+func TestVerifyMFA(t *testing.T) {
+	svc, _, clock, _ := newMFATest(t)
+	ctx := context.Background()
+
+	res := startMFALogin(t, svc)
+	if want := clock.t.Add(mfaChallengeTTL); !res.MFAExpiresAt.Equal(want) {
+		t.Errorf("MFAExpiresAt = %v, want %v", res.MFAExpiresAt, want)
+	}
+
+	pair, err := svc.VerifyMFA(ctx, res.MFAToken, totpCode(t, clock.t), "")
+	if err != nil {
+		t.Fatalf("VerifyMFA: %v", err)
+	}
+	if want := clock.t.Add(15 * time.Minute); !pair.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", pair.ExpiresAt, want)
+	}
+
+	// a completed challenge can't be used again
+	if _, err := svc.VerifyMFA(ctx, res.MFAToken, totpCode(t, clock.t.Add(totp.Period)), ""); err != ErrMFATokenInvalid {
+		t.Errorf("VerifyMFA with a used challenge = %v, want ErrMFATokenInvalid", err)
+	}
+}
+
+func TestVerifyMFAChallengeExpiry(t *testing.T) {
+	svc, _, clock, _ := newMFATest(t)
+	ctx := context.Background()
+
+	res := startMFALogin(t, svc)
+	clock.advance(mfaChallengeTTL)
+	if _, err := svc.VerifyMFA(ctx, res.MFAToken, totpCode(t, clock.t), ""); err != ErrMFATokenInvalid {
+		t.Errorf("VerifyMFA at expiry = %v, want ErrMFATokenInvalid", err)
+	}
+
+	res = startMFALogin(t, svc)
+	clock.advance(mfaChallengeTTL - time.Second)
+	if _, err := svc.VerifyMFA(ctx, res.MFAToken, totpCode(t, clock.t), ""); err != nil {
+		t.Errorf("VerifyMFA just before expiry = %v, want success", err)
+	}
+}
+
+func TestVerifyMFARefusesReusedStep(t *testing.T) {
+	svc, f, clock, acct := newMFATest(t)
+	ctx := context.Background()
+
+	// the current code was already used, say for an earlier login
+	acct.lastUsedStep = totp.Step(clock.t)
+	res := startMFALogin(t, svc)
+	if _, err := svc.VerifyMFA(ctx, res.MFAToken, totpCode(t, clock.t), ""); err != ErrMFACodeInvalid {
+		t.Fatalf("VerifyMFA with a used code = %v, want ErrMFACodeInvalid", err)
+	}
+	if n := f.called("IncrementMfaChallengeAttempts"); n != 1 {
+		t.Errorf("IncrementMfaChallengeAttempts ran %d times, want 1", n)
+	}
+
+	// an earlier step, still within the skew, is refused too
+	if _, err := svc.VerifyMFA(ctx, res.MFAToken, totpCode(t, clock.t.Add(-totp.Period)), ""); err != ErrMFACodeInvalid {
+		t.Fatalf("VerifyMFA with an earlier code = %v, want ErrMFACodeInvalid", err)
+	}
+
+	clock.advance(totp.Period)
+	if _, err := svc.VerifyMFA(ctx, res.MFAToken, totpCode(t, clock.t), ""); err != nil {
+		t.Fatalf("VerifyMFA with the next code: %v", err)
+	}
+}
+
+func TestMFAFailuresLockAccountOnOneClock(t *testing.T) {
+	svc, _, clock, _ := newMFATest(t)
+	ctx := context.Background()
+
+	res := startMFALogin(t, svc)
+	for i := range 3 {
+		if _, err := svc.VerifyMFA(ctx, res.MFAToken, wrongCode(t, clock.t), ""); err != ErrMFACodeInvalid {
+			t.Fatalf("wrong code %d = %v, want ErrMFACodeInvalid", i+1, err)
+		}
+	}
+
+	var locked *AccountLockedError
+	_, err := svc.VerifyMFA(ctx, res.MFAToken, totpCode(t, clock.t), "")
+	if !errors.As(err, &locked) || locked.RetryAfter != 15*time.Minute {
+		t.Fatalf("VerifyMFA after 3 failures = %v, want a 15 minute lock", err)
+	}
+
+	clock.advance(10 * time.Minute)
+	_, err = svc.Login(ctx, mfaTestEmail, mfaTestPassword, "")
+	if !errors.As(err, &locked) || locked.RetryAfter != 5*time.Minute {
+		t.Fatalf("Login 10 minutes in = %v, want the lock to have 5 minutes left", err)
+	}
+
+	clock.advance(5 * time.Minute)
+	res = startMFALogin(t, svc)
+	if _, err := svc.VerifyMFA(ctx, res.MFAToken, totpCode(t, clock.t), ""); err != nil {
+		t.Fatalf("VerifyMFA once the lock is over: %v", err)
+	}
+}
+
+func TestConfirmTOTPEndsSessions(t *testing.T) {
+	svc, f, clock, _ := newMFATest(t)
+	ctx := context.Background()
+
+	f.on("GetUserTotp", func([]driver.Value) (fakeResult, error) {
+		return row(mfaTestSecret, nil, int64(0)), nil
+	})
+	f.answer("ConfirmTotp", affected(1))
+	f.answer("DeleteRecoveryCodes", affected(0))
+	f.answer("CreateRecoveryCode", affected(1))
+	f.answer("RevokeUserRefreshTokens", affected(2))
+	f.answer("BumpTokenVersion", row(int64(1)))
+
+	res, err := svc.ConfirmTOTP(ctx, 1, totpCode(t, clock.t))
+	if err != nil {
+		t.Fatalf("ConfirmTOTP: %v", err)
+	}
+	if len(res.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("got %d recovery codes, want %d", len(res.RecoveryCodes), recoveryCodeCount)
+	}
+	if res.TokenPair == nil || res.RefreshToken == "" {
+		t.Error("ConfirmTOTP returned no token pair")
+	}
+	if f.called("RevokeUserRefreshTokens") != 1 || f.called("BumpTokenVersion") != 1 {
+		t.Error("ConfirmTOTP left earlier sessions alive")
+	}
+	if f.committed() != 1 {
+		t.Errorf("committed %d times, want 1", f.committed())
+	}
+}
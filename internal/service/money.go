@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"strings"
+)
+
+const DefaultCurrency = "USD"
+
+var (
+	ErrCurrencyInvalid  = errors.New("currency_invalid")
+	ErrCurrencyMismatch = errors.New("currency_mismatch")
+)
+
+// supportedCurrencies are the ISO 4217 codes we price in.
+var supportedCurrencies = map[string]bool{
+	"USD": true,
+	"EUR": true,
+	"GBP": true,
+	"CHF": true,
+	"SEK": true,
+	"NOK": true,
+	"DKK": true,
+	"PLN": true,
+	"CZK": true,
+	"HUF": true,
+	"RON": true,
+	"CAD": true,
+}
+
+// Money is an amount in the currency's minor unit (cents for USD/EUR)
+// together with its ISO 4217 code. Amounts in different currencies never
+// combine.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// NormalizeCurrency upper-cases code and checks it is one we support.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !supportedCurrencies[code] {
+		return "", ErrCurrencyInvalid
+	}
+	return code, nil
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Mul(qty int32) Money {
+	return Money{Amount: m.Amount * int64(qty), Currency: m.Currency}
+}
+
+// sumMoney adds up amounts that must all share one currency. An empty input
+// sums to zero in the default currency.
+func sumMoney(ms []Money) (Money, error) {
+	if len(ms) == 0 {
+		return NewMoney(0, DefaultCurrency), nil
+	}
+	total := NewMoney(0, ms[0].Currency)
+	for _, m := range ms {
+		var err error
+		if total, err = total.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
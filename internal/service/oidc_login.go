@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/oidc"
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+var (
+	ErrUnknownProvider     = errors.New("unknown_provider")
+	ErrOIDCStateInvalid    = errors.New("invalid_oidc_state")
+	ErrOIDCEmailUnverified = errors.New("oidc_email_not_verified")
+)
+
+// oidcStateTTL is how long the user has to sign in at the provider.
+const oidcStateTTL = 10 * time.Minute
+
+// OIDCLogin signs users in through external OpenID providers. An external
+// identity is linked to the account with the same email the first time it
+// is used, provided the provider vouches for the address; unknown addresses
+// get a new account without a password.
+type OIDCLogin struct {
+	db        *sql.DB
+	q         *sqlc.Queries
+	auth      *AuthService
+	providers map[string]*oidc.Provider
+}
+
+func NewOIDCLogin(db *sql.DB, q *sqlc.Queries, auth *AuthService, providers []*oidc.Provider) *OIDCLogin {
+	m := make(map[string]*oidc.Provider, len(providers))
+	for _, p := range providers {
+		m[p.Name()] = p
+	}
+	return &OIDCLogin{db: db, q: q, auth: auth, providers: m}
+}
+
+// Start begins a login with provider and returns the URL to send the user
+// to. The PKCE verifier and nonce stay on our side, keyed by the state that
+// comes back with the callback.
+func (s *OIDCLogin) Start(ctx context.Context, provider string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	state, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	u, err := p.AuthCodeURL(ctx, state, nonce, oidc.Challenge(verifier))
+	if err != nil {
+		return "", err
+	}
+	if err := s.q.CreateOidcLoginState(ctx, sqlc.CreateOidcLoginStateParams{
+		StateHash:    hashToken(state),
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    s.auth.now().Add(oidcStateTTL),
+	}); err != nil {
+		return "", err
+	}
+	return u, nil
+}
+
+// Finish completes a login from the provider's callback. Like Login it
+// returns tokens, or an MFA challenge if the account has two factors.
+func (s *OIDCLogin) Finish(ctx context.Context, provider, state, code string) (*LoginResult, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	if state == "" || code == "" {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	// each state is good for one callback
+	st, err := s.q.TakeOidcLoginState(ctx, sqlc.TakeOidcLoginStateParams{
+		StateHash: hashToken(state),
+		Provider:  provider,
+	})
+	if err == sql.ErrNoRows {
+		return nil, ErrOIDCStateInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !s.auth.now().Before(st.ExpiresAt) {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	id, err := p.Exchange(ctx, code, st.CodeVerifier, st.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := s.resolve(ctx, provider, id)
+	if err != nil {
+		return nil, err
+	}
+
+	mfa, err := s.auth.mfaEnabled(ctx, s.q, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa {
+		return s.auth.startMFA(ctx, userID)
+	}
+
+	u, err := s.q.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	family, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	pair, err := s.auth.issue(ctx, s.q, u.ID, u.TokenVersion, family)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{TokenPair: pair}, nil
+}
+
+// resolve finds the account for an external identity, linking or creating
+// one on first use.
+func (s *OIDCLogin) resolve(ctx context.Context, provider string, id *oidc.Identity) (int64, error) {
+	userID, err := s.findOrLink(ctx, provider, id)
+	if isUniqueViolation(err) {
+		// a concurrent first sign-in with the same identity or email got
+		// there first; its account or link is committed now, so look again
+		userID, err = s.findOrLink(ctx, provider, id)
+	}
+	return userID, err
+}
+
+func (s *OIDCLogin) findOrLink(ctx context.Context, provider string, id *oidc.Identity) (int64, error) {
+	userID, err := s.q.GetUserIdentity(ctx, sqlc.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  id.Subject,
+	})
+	if err == nil {
+		return userID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	email := normalizeEmail(id.Email)
+	if email == "" || !id.EmailVerified {
+		return 0, ErrOIDCEmailUnverified
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	sessionsEnded := false
+	u, err := qtx.GetUserByEmail(ctx, email)
+	switch {
+	case err == sql.ErrNoRows:
+		if userID, err = qtx.CreateExternalUser(ctx, email); err != nil {
+			return 0, err
+		}
+	case err != nil:
+		return 0, err
+	default:
+		userID = u.ID
+		full, err := qtx.GetUserByID(ctx, userID)
+		if err != nil {
+			return 0, err
+		}
+		if !full.EmailVerifiedAt.Valid {
+			// Nobody proved they own this address before, so whoever
+			// registered it may not be its owner: the provider's word
+			// wins and the unproven password and sessions go.
+			if err := s.disown(ctx, qtx, userID); err != nil {
+				return 0, err
+			}
+			sessionsEnded = true
+		}
+	}
+
+	if err := qtx.CreateUserIdentity(ctx, sqlc.CreateUserIdentityParams{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  id.Subject,
+		Email:    email,
+	}); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	if sessionsEnded {
+		s.auth.revocations.forget(userID)
+	}
+	return userID, nil
+}
+
+// disown verifies the account's address and drops everything set up
+// through it before that: the password, second factor, sessions and pending
+// emails.
+func (s *OIDCLogin) disown(ctx context.Context, qtx *sqlc.Queries, userID int64) error {
+	if err := qtx.MarkEmailVerified(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.UpdateUserPassword(ctx, sqlc.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: "",
+	}); err != nil {
+		return err
+	}
+	if err := qtx.DeleteUserTotp(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.InvalidateEmailVerificationTokens(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.InvalidateEmailChangeTokens(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.InvalidatePasswordResetTokens(ctx, userID); err != nil {
+		return err
+	}
+	return s.auth.endSessions(ctx, qtx, userID)
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/jwtkeys"
+	"github.com/angelchiav/go-ecommerce/internal/mail"
+	"github.com/angelchiav/go-ecommerce/internal/oidc"
+	"github.com/angelchiav/go-ecommerce/internal/oidc/oidcstub"
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const oidcTestEmail = "grace@example.com"
+
+// newOIDCTest wires an OIDCLogin to a stub provider served over HTTP and a
+// fake database that remembers login states. The identity is not linked
+// yet, and no account uses two factors.
+func newOIDCTest(t *testing.T) (*OIDCLogin, *fakeDB, *oidcstub.Server) {
+	t.Helper()
+	var h http.Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	stub, err := oidcstub.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = stub.Handler()
+
+	f, db := newFakeDB(t)
+	q := sqlc.New(db)
+	clock := &fakeClock{t: time.Now()}
+
+	type loginState struct {
+		verifier, nonce string
+		expiresAt       time.Time
+	}
+	states := map[string]loginState{}
+	f.on("CreateOidcLoginState", func(args []driver.Value) (fakeResult, error) {
+		states[args[0].(string)] = loginState{
+			verifier:  args[2].(string),
+			nonce:     args[3].(string),
+			expiresAt: args[4].(time.Time),
+		}
+		return affected(1), nil
+	})
+	f.on("TakeOidcLoginState", func(args []driver.Value) (fakeResult, error) {
+		st, ok := states[args[0].(string)]
+		if !ok {
+			return noRows(), nil
+		}
+		delete(states, args[0].(string))
+		return row(st.verifier, st.nonce, st.expiresAt), nil
+	})
+	f.answer("GetUserIdentity", noRows())
+	f.answer("CreateUserIdentity", affected(1))
+	f.answer("GetUserTotp", noRows())
+	f.answer("CancelAccountDeletion", affected(0))
+	f.answer("ListUserPermissions", rows())
+	f.answer("CreateRefreshToken", affected(1))
+
+	auth := NewAuthService(db, q, NewRevocationStore(q), jwtkeys.NewHMAC([]byte("test-secret")),
+		mail.NewLogMailer("test@example.com"), NewLoginGuard(NewMemoryAttemptStore(), DefaultAccountPolicy, DefaultIPPolicy, clock.now),
+		AuthOptions{AccessTTL: 15 * time.Minute, RefreshTTL: 24 * time.Hour, Now: clock.now})
+	p := oidc.NewProvider(oidc.Config{
+		Name:        "stub",
+		Issuer:      srv.URL,
+		ClientID:    "shop",
+		RedirectURL: "https://shop.example/v1/auth/oidc/stub/callback",
+	}, srv.Client())
+	return NewOIDCLogin(db, q, auth, []*oidc.Provider{p}), f, stub
+}
+
+// existingAccount makes the fake database hold a password account for
+// oidcTestEmail, with its address verified or not.
+func existingAccount(f *fakeDB, verified bool) {
+	var verifiedAt driver.Value
+	if verified {
+		verifiedAt = time.Now()
+	}
+	f.answer("GetUserByEmail", row(int64(7), oidcTestEmail, "$2a$10$hash", int64(3)))
+	f.answer("GetUserByID", row(int64(7), oidcTestEmail, int64(3), verifiedAt))
+}
+
+// finishOIDC signs email in at the stub and completes the login.
+func finishOIDC(t *testing.T, s *OIDCLogin, stub *oidcstub.Server, email string) (*LoginResult, error) {
+	t.Helper()
+	ctx := context.Background()
+	u, err := s.Start(ctx, "stub")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	code, state, err := stub.SignIn(u, email)
+	if err != nil {
+		t.Fatalf("SignIn: %v", err)
+	}
+	return s.Finish(ctx, "stub", state, code)
+}
+
+func TestOIDCFinishLinksVerifiedAccount(t *testing.T) {
+	s, f, stub := newOIDCTest(t)
+	stub.AddUser(oidcStub(true))
+	existingAccount(f, true)
+
+	var linked []driver.Value
+	f.on("CreateUserIdentity", func(args []driver.Value) (fakeResult, error) {
+		linked = args
+		return affected(1), nil
+	})
+
+	res, err := finishOIDC(t, s, stub, oidcTestEmail)
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if res.TokenPair == nil || res.UserID != 7 {
+		t.Fatalf("Finish = %+v, want tokens for user 7", res)
+	}
+	if len(linked) != 4 || linked[0] != int64(7) || linked[1] != "stub" || linked[2] != "sub-7" {
+		t.Errorf("CreateUserIdentity(%v), want user 7 linked to stub/sub-7", linked)
+	}
+	// a verified account keeps its password and sessions; the fake
+	// database would have failed the test on any disown query
+	if f.committed() != 1 {
+		t.Errorf("committed %d times, want 1", f.committed())
+	}
+}
+
+func TestOIDCFinishDisownsUnverifiedAccount(t *testing.T) {
+	s, f, stub := newOIDCTest(t)
+	stub.AddUser(oidcStub(true))
+	existingAccount(f, false)
+
+	var newHash driver.Value = "unchanged"
+	f.on("UpdateUserPassword", func(args []driver.Value) (fakeResult, error) {
+		newHash = args[1]
+		return affected(1), nil
+	})
+	disown := []string{
+		"MarkEmailVerified",
+		"DeleteUserTotp",
+		"DeleteRecoveryCodes",
+		"InvalidateEmailVerificationTokens",
+		"InvalidateEmailChangeTokens",
+		"InvalidatePasswordResetTokens",
+		"RevokeUserRefreshTokens",
+	}
+	for _, name := range disown {
+		f.answer(name, affected(1))
+	}
+	f.answer("BumpTokenVersion", row(int64(4)))
+
+	res, err := finishOIDC(t, s, stub, oidcTestEmail)
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if res.TokenPair == nil {
+		t.Fatalf("Finish = %+v, want tokens", res)
+	}
+	if newHash != "" {
+		t.Errorf("password hash set to %q, want it cleared", newHash)
+	}
+	for _, name := range append(disown, "BumpTokenVersion", "CreateUserIdentity") {
+		if n := f.called(name); n != 1 {
+			t.Errorf("%s ran %d times, want 1", name, n)
+		}
+	}
+}
+
+func TestOIDCFinishRefusesUnverifiedEmail(t *testing.T) {
+	s, f, stub := newOIDCTest(t)
+	stub.AddUser(oidcStub(false))
+
+	if _, err := finishOIDC(t, s, stub, oidcTestEmail); err != ErrOIDCEmailUnverified {
+		t.Fatalf("Finish = %v, want ErrOIDCEmailUnverified", err)
+	}
+	if n := f.called("CreateUserIdentity"); n != 0 {
+		t.Errorf("CreateUserIdentity ran %d times, want 0", n)
+	}
+}
+
+func TestOIDCFinishRejectsBadIDTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(jwt.MapClaims)
+	}{
+		{"wrong nonce", func(c jwt.MapClaims) { c["nonce"] = "replayed-nonce" }},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, f, stub := newOIDCTest(t)
+			stub.AddUser(oidcStub(true))
+			stub.EditClaims = tt.edit
+
+			if _, err := finishOIDC(t, s, stub, oidcTestEmail); !errors.Is(err, oidc.ErrIDTokenInvalid) {
+				t.Fatalf("Finish = %v, want ErrIDTokenInvalid", err)
+			}
+			if n := f.called("GetUserIdentity"); n != 0 {
+				t.Errorf("GetUserIdentity ran %d times, want 0", n)
+			}
+		})
+	}
+}
+
+func TestOIDCFinishStateIsSingleUse(t *testing.T) {
+	s, _, stub := newOIDCTest(t)
+	stub.AddUser(oidcStub(true))
+	ctx := context.Background()
+
+	u, err := s.Start(ctx, "stub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state, err := stub.SignIn(u, oidcTestEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first callback fails at the provider, and still uses up the state
+	if _, err := s.Finish(ctx, "stub", state, "not-the-code"); !errors.Is(err, oidc.ErrExchange) {
+		t.Fatalf("Finish with a bad code = %v, want ErrExchange", err)
+	}
+	if _, err := s.Finish(ctx, "stub", state, code); err != ErrOIDCStateInvalid {
+		t.Errorf("Finish with a used state = %v, want ErrOIDCStateInvalid", err)
+	}
+}
+
+// uniqueViolation is what Postgres says when a concurrent transaction
+// committed the row first.
+var uniqueViolation = &pgconn.PgError{Code: "23505"}
+
+func TestOIDCFinishLosesLinkRace(t *testing.T) {
+	s, f, stub := newOIDCTest(t)
+	stub.AddUser(oidcStub(true))
+	existingAccount(f, true)
+
+	// a concurrent first sign-in links the identity after we looked for it
+	lookups := 0
+	f.on("GetUserIdentity", func([]driver.Value) (fakeResult, error) {
+		lookups++
+		if lookups == 1 {
+			return noRows(), nil
+		}
+		return row(int64(7)), nil
+	})
+	f.on("CreateUserIdentity", func([]driver.Value) (fakeResult, error) {
+		return fakeResult{}, uniqueViolation
+	})
+
+	res, err := finishOIDC(t, s, stub, oidcTestEmail)
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if res.UserID != 7 {
+		t.Errorf("signed in as user %d, want 7", res.UserID)
+	}
+}
+
+func TestOIDCFinishLosesSignUpRace(t *testing.T) {
+	s, f, stub := newOIDCTest(t)
+	stub.AddUser(oidcStub(true))
+
+	// the email is new on our first look, and taken by another provider's
+	// first sign-in when we try to create its account
+	created := false
+	f.on("GetUserByEmail", func([]driver.Value) (fakeResult, error) {
+		if !created {
+			return noRows(), nil
+		}
+		return row(int64(8), oidcTestEmail, "", int64(0)), nil
+	})
+	f.on("CreateExternalUser", func([]driver.Value) (fakeResult, error) {
+		created = true
+		return fakeResult{}, uniqueViolation
+	})
+	f.answer("GetUserByID", row(int64(8), oidcTestEmail, int64(0), time.Now()))
+
+	res, err := finishOIDC(t, s, stub, oidcTestEmail)
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if res.UserID != 8 {
+		t.Errorf("signed in as user %d, want 8", res.UserID)
+	}
+	if n := f.called("CreateUserIdentity"); n != 1 {
+		t.Errorf("CreateUserIdentity ran %d times, want 1", n)
+	}
+}
+
+func oidcStub(emailVerified bool) oidcstub.User {
+	return oidcstub.User{Subject: "sub-7", Email: oidcTestEmail, EmailVerified: emailVerified}
+}
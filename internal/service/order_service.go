@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+var (
+	ErrOrderNotFound = errors.New("order_not_found")
+)
+
+type OrderItem struct {
+	ProductID int64  `json:"product_id"`
+	Name      string `json:"name"`
+	Qty       int32  `json:"qty"`
+	UnitPrice Money  `json:"unit_price"`
+	LineTotal Money  `json:"line_total"`
+}
+
+type Order struct {
+	ID              int64          `json:"id"`
+	Status          string         `json:"status"`
+	Total           Money          `json:"total"`
+	CreatedAt       time.Time      `json:"created_at"`
+	Items           []OrderItem    `json:"items,omitempty"`
+	ShippingAddress *PostalAddress `json:"shipping_address,omitempty"`
+	BillingAddress  *PostalAddress `json:"billing_address,omitempty"`
+}
+
+type OrderFilter struct {
+	Status string
+	Cursor string
+	Limit  int
+}
+
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type OrderStatusChange struct {
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	ChangedBy  int64     `json:"changed_by,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type orderCursor struct {
+	ID int64 `json:"id"`
+}
+
+type OrderService struct {
+	q  *sqlc.Queries
+	db *sql.DB
+}
+
+func NewOrderService(db *sql.DB, q *sqlc.Queries) *OrderService {
+	return &OrderService{db: db, q: q}
+}
+
+// List returns the user's orders newest first. Pages are keyed on the order
+// id, so new orders placed while paging never shift later pages.
+func (s *OrderService) List(ctx context.Context, userID int64, f OrderFilter) (*OrderPage, error) {
+	if f.Status != "" && !ValidOrderStatus(f.Status) {
+		return nil, ErrStatusInvalid
+	}
+
+	var cur orderCursor
+	if f.Cursor != "" {
+		if err := decodeCursor(f.Cursor, &cur); err != nil {
+			return nil, err
+		}
+	}
+	size := clampPageSize(f.Limit)
+
+	rows, err := s.q.ListOrdersForUser(ctx, sqlc.ListOrdersForUserParams{
+		UserID:   userID,
+		Status:   f.Status,
+		BeforeID: cur.ID,
+		PageSize: size + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &OrderPage{Orders: make([]Order, 0, len(rows))}
+	if len(rows) > int(size) {
+		rows = rows[:size]
+		page.NextCursor = encodeCursor(orderCursor{ID: rows[len(rows)-1].ID})
+	}
+	for _, o := range rows {
+		page.Orders = append(page.Orders, Order{
+			ID:        o.ID,
+			Status:    o.Status,
+			Total:     NewMoney(o.TotalCents, o.Currency),
+			CreatedAt: o.CreatedAt,
+		})
+	}
+	return page, nil
+}
+
+func (s *OrderService) Get(ctx context.Context, userID, orderID int64) (*Order, error) {
+	o, err := s.q.GetOrderForUser(ctx, sqlc.GetOrderForUserParams{
+		ID:     orderID,
+		UserID: userID,
+	})
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.q.ListOrderItems(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]OrderItem, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, OrderItem{
+			ProductID: r.ProductID,
+			Name:      r.Name,
+			Qty:       r.Qty,
+			UnitPrice: NewMoney(r.UnitPriceCents, o.Currency),
+			LineTotal: NewMoney(r.LineTotalCents, o.Currency),
+		})
+	}
+
+	addrs, err := s.q.ListOrderAddresses(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Order{
+		ID:        o.ID,
+		Status:    o.Status,
+		Total:     NewMoney(o.TotalCents, o.Currency),
+		CreatedAt: o.CreatedAt,
+		Items:     items,
+	}
+	out.setAddresses(addrs)
+	return out, nil
+}
+
+// setAddresses fills in the addresses the order was placed with. Orders
+// from before addresses were recorded have none.
+func (o *Order) setAddresses(rows []sqlc.OrderAddress) {
+	for _, r := range rows {
+		a := &PostalAddress{
+			FullName:   r.FullName,
+			Company:    r.Company,
+			Line1:      r.Line1,
+			Line2:      r.Line2,
+			City:       r.City,
+			Region:     r.Region,
+			PostalCode: r.PostalCode,
+			Country:    r.Country,
+			Phone:      r.Phone,
+		}
+		switch r.Kind {
+		case AddressShipping:
+			o.ShippingAddress = a
+		case AddressBilling:
+			o.BillingAddress = a
+		}
+	}
+}
+
+// Transition moves an order to a new status on behalf of actorID, enforcing
+// the lifecycle in orderTransitions and recording the change in the
+// order's status history.
+func (s *OrderService) Transition(ctx context.Context, orderID int64, to string, actorID int64, reason string) (*Order, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	o, err := qtx.GetOrderForUpdate(ctx, orderID)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transitionOrder(ctx, qtx, o, to, actorID, reason); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &Order{
+		ID:        o.ID,
+		Status:    to,
+		Total:     NewMoney(o.TotalCents, o.Currency),
+		CreatedAt: o.CreatedAt,
+	}, nil
+}
+
+// Cancel lets a customer cancel one of their own orders while it is still in
+// a cancellable state. Stock for every line is returned in the same
+// transaction as the status change.
+func (s *OrderService) Cancel(ctx context.Context, userID, orderID int64, reason string) (*Order, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	o, err := qtx.GetOrderForUpdate(ctx, orderID)
+	if err == sql.ErrNoRows || (err == nil && o.UserID != userID) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !customerCancellable[o.Status] {
+		return nil, &TransitionError{Current: o.Status, Attempted: OrderCancelled}
+	}
+	if reason == "" {
+		reason = "cancelled by customer"
+	}
+
+	if err := transitionOrder(ctx, qtx, o, OrderCancelled, userID, reason); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &Order{
+		ID:        o.ID,
+		Status:    OrderCancelled,
+		Total:     NewMoney(o.TotalCents, o.Currency),
+		CreatedAt: o.CreatedAt,
+	}, nil
+}
+
+func (s *OrderService) History(ctx context.Context, orderID int64) ([]OrderStatusChange, error) {
+	if _, err := s.q.GetOrder(ctx, orderID); err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.q.ListOrderStatusHistory(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]OrderStatusChange, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, OrderStatusChange{
+			FromStatus: r.FromStatus.String,
+			ToStatus:   r.ToStatus,
+			ChangedBy:  r.ChangedBy.Int64,
+			Reason:     r.Reason,
+			CreatedAt:  r.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+// transitionOrder applies a status change to an order row that the caller
+// has already locked inside qtx's transaction.
+func transitionOrder(ctx context.Context, qtx *sqlc.Queries, o sqlc.Order, to string, actorID int64, reason string) error {
+	if err := checkTransition(o.Status, to); err != nil {
+		return err
+	}
+
+	if err := qtx.UpdateOrderStatus(ctx, sqlc.UpdateOrderStatusParams{
+		ID:     o.ID,
+		Status: to,
+	}); err != nil {
+		return err
+	}
+
+	if to == OrderCancelled {
+		if err := restockOrder(ctx, qtx, o.ID); err != nil {
+			return err
+		}
+	}
+
+	return qtx.InsertOrderStatusHistory(ctx, sqlc.InsertOrderStatusHistoryParams{
+		OrderID:    o.ID,
+		FromStatus: sql.NullString{String: o.Status, Valid: true},
+		ToStatus:   to,
+		ChangedBy:  sql.NullInt64{Int64: actorID, Valid: actorID > 0},
+		Reason:     reason,
+	})
+}
+
+// restockOrder puts every line of an order back into products.stock. Rows are
+// updated in product id order, the same order checkout locks them in.
+func restockOrder(ctx context.Context, qtx *sqlc.Queries, orderID int64) error {
+	rows, err := qtx.ListOrderItems(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ProductID < rows[j].ProductID })
+
+	for _, r := range rows {
+		if err := qtx.IncrementProductStock(ctx, sqlc.IncrementProductStockParams{
+			ID:    r.ProductID,
+			Stock: r.Qty,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+)
+
+const (
+	OrderPlaced    = "placed"
+	OrderPaid      = "paid"
+	OrderFulfilled = "fulfilled"
+	OrderShipped   = "shipped"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+	OrderRefunded  = "refunded"
+)
+
+var ErrStatusInvalid = errors.New("status_invalid")
+
+// orderTransitions is the order lifecycle. Anything not listed here is an
+// illegal transition; cancelled and refunded are terminal.
+var orderTransitions = map[string][]string{
+	OrderPlaced:    {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderFulfilled, OrderRefunded},
+	OrderFulfilled: {OrderShipped, OrderRefunded},
+	OrderShipped:   {OrderDelivered},
+	OrderDelivered: {OrderRefunded},
+	OrderCancelled: {},
+	OrderRefunded:  {},
+}
+
+// customerCancellable lists the states in which a customer may still cancel
+// their own order; past these it has to go through support.
+var customerCancellable = map[string]bool{
+	OrderPlaced: true,
+}
+
+// TransitionError reports an attempt to move an order to a state that is not
+// reachable from its current one.
+type TransitionError struct {
+	Current   string
+	Attempted string
+}
+
+func (e *TransitionError) Error() string { return "invalid_transition" }
+
+func ValidOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+func CanTransition(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func checkTransition(from, to string) error {
+	if !ValidOrderStatus(to) {
+		return ErrStatusInvalid
+	}
+	if !CanTransition(from, to) {
+		return &TransitionError{Current: from, Attempted: to}
+	}
+	return nil
+}
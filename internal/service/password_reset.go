@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/angelchiav/go-ecommerce/internal/mail"
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrResetTokenInvalid = errors.New("invalid_reset_token")
+
+// ForgotPassword emails a reset link if email belongs to an account. The
+// lookup, the token and the mail are all handled in the background, so the
+// call takes as long for an unknown address as for a registered one and
+// callers can't tell them apart.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := s.sendPasswordReset(ctx, normalizeEmail(email)); err != nil {
+			log.Printf("password reset error: %v", err)
+		}
+	}()
+}
+
+func (s *AuthService) sendPasswordReset(ctx context.Context, email string) error {
+	u, err := s.q.GetUserByEmail(ctx, email)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	// only the most recent link works
+	if err := qtx.InvalidatePasswordResetTokens(ctx, u.ID); err != nil {
+		return err
+	}
+	if err := qtx.CreatePasswordResetToken(ctx, sqlc.CreatePasswordResetTokenParams{
+		UserID:    u.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: s.now().Add(s.opts.PasswordResetTTL),
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	link := s.opts.AppURL + "/reset-password?token=" + url.QueryEscape(token)
+	s.sendMail(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password for this account.\n\n"+
+			"Follow this link within %s to choose a new one:\n\n%s\n\n"+
+			"If it wasn't you, ignore this email; your password is unchanged.\n",
+			s.opts.PasswordResetTTL, link),
+	})
+	return nil
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword. The
+// token works once, and every existing session of the user is ended.
+func (s *AuthService) ResetPassword(ctx context.Context, token, password string) error {
+	if len(password) < minPasswordLen {
+		return ErrPasswordTooShort
+	}
+	if token == "" {
+		return ErrResetTokenInvalid
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	userID, err := qtx.ConsumePasswordResetToken(ctx, hashToken(token))
+	if err == sql.ErrNoRows {
+		return ErrResetTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := qtx.UpdateUserPassword(ctx, sqlc.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: string(hash),
+	}); err != nil {
+		return err
+	}
+	if err := s.endSessions(ctx, qtx, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.revocations.forget(userID)
+	return nil
+}
+
+// endSessions revokes every refresh token of the user and invalidates their
+// access tokens. The caller must forget the user in the revocation cache
+// once the transaction commits.
+func (s *AuthService) endSessions(ctx context.Context, qtx *sqlc.Queries, userID int64) error {
+	if err := qtx.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+	_, err := qtx.BumpTokenVersion(ctx, userID)
+	return err
+}
+
+// sendMail delivers m without holding up the request; failures are logged.
+func (s *AuthService) sendMail(ctx context.Context, m mail.Message) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := s.mailer.Send(ctx, m); err != nil {
+			log.Printf("mail to %s error: %v", m.To, err)
+		}
+	}()
+}
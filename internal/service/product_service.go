@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+var (
+	ErrProductNotFound = errors.New("product_not_found")
+	ErrSortInvalid     = errors.New("sort_invalid")
+	ErrPriceRange      = errors.New("price_range_invalid")
+	ErrNameInvalid     = errors.New("name_invalid")
+	ErrDescInvalid     = errors.New("description_invalid")
+	ErrPriceInvalid    = errors.New("price_invalid")
+	ErrStockInvalid    = errors.New("stock_invalid")
+
+	// ErrCurrencyRequired means prices were filtered or sorted on without
+	// a currency; amounts in different currencies don't compare.
+	ErrCurrencyRequired = errors.New("currency_required")
+)
+
+const (
+	maxProductNameLen = 200
+	maxProductDescLen = 5000
+	maxPriceCents     = 100_000_000
+)
+
+const (
+	SortNewest    = "newest"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortName      = "name"
+)
+
+type Product struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Price       Money     `json:"price"`
+	Stock       int32     `json:"stock"`
+	Available   *int32    `json:"available,omitempty"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ProductInput struct {
+	Name        string
+	Description string
+	PriceCents  int64
+	Currency    string
+	Stock       int32
+}
+
+// ProductPatch holds the fields of a partial update; nil fields are left
+// untouched.
+type ProductPatch struct {
+	Name        *string
+	Description *string
+	PriceCents  *int64
+	Currency    *string
+	Stock       *int32
+	IsActive    *bool
+}
+
+type ProductFilter struct {
+	Query         string
+	MinPriceCents *int64
+	MaxPriceCents *int64
+	Currency      string
+	Sort          string
+	Cursor        string
+	Limit         int
+}
+
+type ProductPage struct {
+	Products   []Product `json:"products"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// productCursor carries the keyset position for whichever sort produced it.
+// The sort is part of the cursor so a cursor can't be replayed against a
+// different ordering.
+type productCursor struct {
+	Sort  string `json:"s"`
+	ID    int64  `json:"id"`
+	Price int64  `json:"p,omitempty"`
+	Name  string `json:"n,omitempty"`
+}
+
+type ProductService struct {
+	q *sqlc.Queries
+}
+
+func NewProductService(q *sqlc.Queries) *ProductService {
+	return &ProductService{q: q}
+}
+
+func (s *ProductService) Get(ctx context.Context, id int64) (*Product, error) {
+	p, err := s.q.GetActiveProduct(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	v := catalogView(p)
+	return &v, nil
+}
+
+func (s *ProductService) List(ctx context.Context, f ProductFilter) (*ProductPage, error) {
+	if f.Sort == "" {
+		f.Sort = SortNewest
+	}
+	if f.MinPriceCents != nil && f.MaxPriceCents != nil && *f.MinPriceCents > *f.MaxPriceCents {
+		return nil, ErrPriceRange
+	}
+	byPrice := f.MinPriceCents != nil || f.MaxPriceCents != nil || f.Sort == SortPriceAsc || f.Sort == SortPriceDesc
+	if byPrice && f.Currency == "" {
+		return nil, ErrCurrencyRequired
+	}
+	if f.Currency != "" {
+		c, err := NormalizeCurrency(f.Currency)
+		if err != nil {
+			return nil, err
+		}
+		f.Currency = c
+	}
+
+	var cur *productCursor
+	if f.Cursor != "" {
+		cur = &productCursor{}
+		if err := decodeCursor(f.Cursor, cur); err != nil {
+			return nil, err
+		}
+		if cur.Sort != f.Sort {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	search := escapeLike(strings.TrimSpace(f.Query))
+	minPrice := nullPrice(f.MinPriceCents)
+	maxPrice := nullPrice(f.MaxPriceCents)
+	size := clampPageSize(f.Limit)
+
+	var afterID int64
+	var afterPrice sql.NullInt64
+	var afterName sql.NullString
+	if cur != nil {
+		afterID = cur.ID
+		afterPrice = sql.NullInt64{Int64: cur.Price, Valid: true}
+		afterName = sql.NullString{String: cur.Name, Valid: true}
+	}
+
+	// every catalog query selects the same columns, so their row types convert
+	// into one another
+	var rows []sqlc.GetActiveProductRow
+
+	switch f.Sort {
+	case SortNewest:
+		res, err := s.q.ListProductsNewest(ctx, sqlc.ListProductsNewestParams{
+			Search:   search,
+			MinPrice: minPrice,
+			MaxPrice: maxPrice,
+			Currency: f.Currency,
+			AfterID:  afterID,
+			PageSize: size + 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			rows = append(rows, sqlc.GetActiveProductRow(r))
+		}
+	case SortPriceAsc:
+		res, err := s.q.ListProductsPriceAsc(ctx, sqlc.ListProductsPriceAscParams{
+			Search:     search,
+			MinPrice:   minPrice,
+			MaxPrice:   maxPrice,
+			Currency:   f.Currency,
+			AfterPrice: afterPrice,
+			AfterID:    afterID,
+			PageSize:   size + 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			rows = append(rows, sqlc.GetActiveProductRow(r))
+		}
+	case SortPriceDesc:
+		res, err := s.q.ListProductsPriceDesc(ctx, sqlc.ListProductsPriceDescParams{
+			Search:     search,
+			MinPrice:   minPrice,
+			MaxPrice:   maxPrice,
+			Currency:   f.Currency,
+			AfterPrice: afterPrice,
+			AfterID:    afterID,
+			PageSize:   size + 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			rows = append(rows, sqlc.GetActiveProductRow(r))
+		}
+	case SortName:
+		res, err := s.q.ListProductsName(ctx, sqlc.ListProductsNameParams{
+			Search:    search,
+			MinPrice:  minPrice,
+			MaxPrice:  maxPrice,
+			Currency:  f.Currency,
+			AfterName: afterName,
+			AfterID:   afterID,
+			PageSize:  size + 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			rows = append(rows, sqlc.GetActiveProductRow(r))
+		}
+	default:
+		return nil, ErrSortInvalid
+	}
+
+	page := &ProductPage{Products: make([]Product, 0, len(rows))}
+	if len(rows) > int(size) {
+		rows = rows[:size]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(productCursor{
+			Sort:  f.Sort,
+			ID:    last.ID,
+			Price: last.PriceCents,
+			Name:  last.Name,
+		})
+	}
+	for _, p := range rows {
+		page.Products = append(page.Products, catalogView(p))
+	}
+	return page, nil
+}
+
+func (s *ProductService) Create(ctx context.Context, in ProductInput) (*Product, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Description = strings.TrimSpace(in.Description)
+	if err := validateProduct(&in.Name, &in.Description, &in.PriceCents, &in.Stock); err != nil {
+		return nil, err
+	}
+	if in.Currency == "" {
+		in.Currency = DefaultCurrency
+	}
+	currency, err := NormalizeCurrency(in.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.q.CreateProduct(ctx, sqlc.CreateProductParams{
+		Name:        in.Name,
+		Description: in.Description,
+		PriceCents:  in.PriceCents,
+		Currency:    currency,
+		Stock:       in.Stock,
+	})
+	if err != nil {
+		return nil, err
+	}
+	v := productView(p)
+	return &v, nil
+}
+
+func (s *ProductService) Update(ctx context.Context, id int64, in ProductPatch) (*Product, error) {
+	if in.Name != nil {
+		n := strings.TrimSpace(*in.Name)
+		in.Name = &n
+	}
+	if in.Description != nil {
+		d := strings.TrimSpace(*in.Description)
+		in.Description = &d
+	}
+	if err := validateProduct(in.Name, in.Description, in.PriceCents, in.Stock); err != nil {
+		return nil, err
+	}
+
+	arg := sqlc.UpdateProductParams{ID: id}
+	if in.Name != nil {
+		arg.Name = sql.NullString{String: *in.Name, Valid: true}
+	}
+	if in.Description != nil {
+		arg.Description = sql.NullString{String: *in.Description, Valid: true}
+	}
+	if in.PriceCents != nil {
+		arg.PriceCents = sql.NullInt64{Int64: *in.PriceCents, Valid: true}
+	}
+	if in.Currency != nil {
+		c, err := NormalizeCurrency(*in.Currency)
+		if err != nil {
+			return nil, err
+		}
+		arg.Currency = sql.NullString{String: c, Valid: true}
+	}
+	if in.Stock != nil {
+		arg.Stock = sql.NullInt32{Int32: *in.Stock, Valid: true}
+	}
+	if in.IsActive != nil {
+		arg.IsActive = sql.NullBool{Bool: *in.IsActive, Valid: true}
+	}
+
+	p, err := s.q.UpdateProduct(ctx, arg)
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	v := productView(p)
+	return &v, nil
+}
+
+// Deactivate soft-deletes a product. It disappears from the catalog and can
+// no longer be checked out, but existing carts and orders keep referencing it.
+func (s *ProductService) Deactivate(ctx context.Context, id int64) error {
+	n, err := s.q.DeactivateProduct(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// validateProduct checks whichever fields are present; nil means the field is
+// not being set.
+func validateProduct(name, desc *string, priceCents *int64, stock *int32) error {
+	if name != nil && (*name == "" || len(*name) > maxProductNameLen) {
+		return ErrNameInvalid
+	}
+	if desc != nil && len(*desc) > maxProductDescLen {
+		return ErrDescInvalid
+	}
+	if priceCents != nil && (*priceCents < 0 || *priceCents > maxPriceCents) {
+		return ErrPriceInvalid
+	}
+	if stock != nil && *stock < 0 {
+		return ErrStockInvalid
+	}
+	return nil
+}
+
+func productView(p sqlc.Product) Product {
+	return Product{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       NewMoney(p.PriceCents, p.Currency),
+		Stock:       p.Stock,
+		IsActive:    p.IsActive,
+		CreatedAt:   p.CreatedAt,
+	}
+}
+
+func nullPrice(cents *int64) sql.NullInt64 {
+	if cents == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *cents, Valid: true}
+}
+
+// catalogView is productView plus the stock not currently held by any cart.
+func catalogView(r sqlc.GetActiveProductRow) Product {
+	v := productView(sqlc.Product{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		PriceCents:  r.PriceCents,
+		Stock:       r.Stock,
+		IsActive:    r.IsActive,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+		Currency:    r.Currency,
+	})
+	available := max(r.Available, 0)
+	v.Available = &available
+	return v
+}
+
+// escapeLike makes user input match literally inside an ILIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
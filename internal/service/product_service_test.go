@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+func TestListByPriceNeedsCurrency(t *testing.T) {
+	price := int64(1000)
+	tests := []struct {
+		name string
+		f    ProductFilter
+	}{
+		{"min price", ProductFilter{MinPriceCents: &price}},
+		{"max price", ProductFilter{MaxPriceCents: &price}},
+		{"price ascending", ProductFilter{Sort: SortPriceAsc}},
+		{"price descending", ProductFilter{Sort: SortPriceDesc}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the fake database fails the test on any query
+			_, db := newFakeDB(t)
+			s := NewProductService(sqlc.New(db))
+			if _, err := s.List(context.Background(), tt.f); err != ErrCurrencyRequired {
+				t.Errorf("List = %v, want ErrCurrencyRequired", err)
+			}
+		})
+	}
+}
+
+func TestListByPriceInCurrency(t *testing.T) {
+	f, db := newFakeDB(t)
+	var currency driver.Value
+	f.on("ListProductsPriceAsc", func(args []driver.Value) (fakeResult, error) {
+		currency = args[3]
+		return rows(), nil
+	})
+	s := NewProductService(sqlc.New(db))
+
+	price := int64(1000)
+	if _, err := s.List(context.Background(), ProductFilter{Sort: SortPriceAsc, MinPriceCents: &price, Currency: "eur"}); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if currency != "EUR" {
+		t.Errorf("listed products in %v, want EUR", currency)
+	}
+}
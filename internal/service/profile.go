@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+var (
+	ErrFirstNameInvalid = errors.New("first_name_invalid")
+	ErrLastNameInvalid  = errors.New("last_name_invalid")
+)
+
+// Profile is the personal details a user keeps on their account. Every field
+// is optional; the email is read-only here and changes through the
+// confirmation flow.
+type Profile struct {
+	ID        int64  `json:"id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
+}
+
+type ProfileInput struct {
+	FirstName string
+	LastName  string
+	Phone     string
+}
+
+type ProfileService struct {
+	q *sqlc.Queries
+}
+
+func NewProfileService(q *sqlc.Queries) *ProfileService {
+	return &ProfileService{q: q}
+}
+
+func (s *ProfileService) Get(ctx context.Context, userID int64) (*Profile, error) {
+	p, err := s.q.GetUserProfile(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Profile{ID: p.ID, Email: p.Email, FirstName: p.FirstName, LastName: p.LastName, Phone: p.Phone}, nil
+}
+
+// Update replaces the profile; empty fields are cleared.
+func (s *ProfileService) Update(ctx context.Context, userID int64, in ProfileInput) (*Profile, error) {
+	in.FirstName = collapseSpace(in.FirstName)
+	in.LastName = collapseSpace(in.LastName)
+	in.Phone = collapseSpace(in.Phone)
+	switch {
+	case !validText(in.FirstName, 0, maxNameLen):
+		return nil, ErrFirstNameInvalid
+	case !validText(in.LastName, 0, maxNameLen):
+		return nil, ErrLastNameInvalid
+	case !validPhone(in.Phone):
+		return nil, ErrPhoneInvalid
+	}
+
+	p, err := s.q.UpdateUserProfile(ctx, sqlc.UpdateUserProfileParams{
+		ID:        userID,
+		FirstName: in.FirstName,
+		LastName:  in.LastName,
+		Phone:     in.Phone,
+	})
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Profile{ID: p.ID, Email: p.Email, FirstName: p.FirstName, LastName: p.LastName, Phone: p.Phone}, nil
+}
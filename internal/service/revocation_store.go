@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+// revocationCacheTTL bounds how long another instance's logout can go
+// unnoticed here. Revocations made through this store take effect at once.
+const revocationCacheTTL = 30 * time.Second
+
+type tokenState struct {
+	userID  int64
+	version int32
+	revoked bool
+	until   time.Time
+}
+
+// RevocationStore answers whether an access token has been revoked, either
+// individually by jti (logout) or by its user's token version moving past the
+// one in the token (log out all sessions). Postgres is the source of truth;
+// lookups are cached in memory per jti so most requests don't hit the
+// database.
+type RevocationStore struct {
+	q *sqlc.Queries
+
+	mu    sync.Mutex
+	cache map[string]tokenState
+}
+
+func NewRevocationStore(q *sqlc.Queries) *RevocationStore {
+	return &RevocationStore{q: q, cache: map[string]tokenState{}}
+}
+
+func (s *RevocationStore) IsRevoked(ctx context.Context, userID int64, jti string, version int32) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	st, ok := s.cache[jti]
+	s.mu.Unlock()
+
+	if !ok || st.userID != userID || !now.Before(st.until) {
+		row, err := s.q.GetTokenState(ctx, sqlc.GetTokenStateParams{
+			ID:  userID,
+			Jti: jti,
+		})
+		if err == sql.ErrNoRows {
+			// the user is gone
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		st = tokenState{
+			userID:  userID,
+			version: row.TokenVersion,
+			revoked: row.Revoked,
+			until:   now.Add(revocationCacheTTL),
+		}
+		s.mu.Lock()
+		s.cache[jti] = st
+		s.mu.Unlock()
+	}
+
+	return st.revoked || version < st.version, nil
+}
+
+// Revoke blocks a single access token until it expires.
+func (s *RevocationStore) Revoke(ctx context.Context, userID int64, jti string, expiresAt time.Time) error {
+	if err := s.q.RevokeToken(ctx, sqlc.RevokeTokenParams{
+		Jti:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.cache[jti] = tokenState{userID: userID, revoked: true, until: expiresAt}
+	s.mu.Unlock()
+	return nil
+}
+
+// forget drops cached state for userID so the next check reloads it.
+func (s *RevocationStore) forget(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for jti, st := range s.cache {
+		if st.userID == userID {
+			delete(s.cache, jti)
+		}
+	}
+}
+
+// Purge deletes revocations of tokens that have expired anyway and drops
+// stale cache entries; it is meant to be run by a Sweeper.
+func (s *RevocationStore) Purge(ctx context.Context) (int64, error) {
+	now := time.Now()
+	s.mu.Lock()
+	for jti, st := range s.cache {
+		if !now.Before(st.until) {
+			delete(s.cache, jti)
+		}
+	}
+	s.mu.Unlock()
+
+	return s.q.DeleteExpiredRevokedTokens(ctx)
+}
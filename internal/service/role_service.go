@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/angelchiav/go-ecommerce/internal/sqlc"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role_not_found")
+	ErrOwnRoles     = errors.New("cannot_change_own_roles")
+	ErrRoleNotHeld  = errors.New("role_not_assigned")
+	ErrRoleRequired = errors.New("role_required")
+)
+
+// Role is a named set of permissions.
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleService assigns roles to users. Access tokens carry the permissions
+// their user had when they were issued, so changing someone's roles revokes
+// their access tokens; their next refresh picks up the new permissions.
+type RoleService struct {
+	db          *sql.DB
+	q           *sqlc.Queries
+	revocations *RevocationStore
+}
+
+func NewRoleService(db *sql.DB, q *sqlc.Queries, revocations *RevocationStore) *RoleService {
+	return &RoleService{db: db, q: q, revocations: revocations}
+}
+
+func (s *RoleService) List(ctx context.Context) ([]Role, error) {
+	roles, err := s.q.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := s.q.ListRolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := map[string][]string{}
+	for _, g := range grants {
+		perms[g.Role] = append(perms[g.Role], g.Permission)
+	}
+
+	out := make([]Role, 0, len(roles))
+	for _, r := range roles {
+		p := perms[r.Name]
+		if p == nil {
+			p = []string{}
+		}
+		out = append(out, Role{Name: r.Name, Description: r.Description, Permissions: p})
+	}
+	return out, nil
+}
+
+func (s *RoleService) UserRoles(ctx context.Context, userID int64) ([]string, error) {
+	if _, err := s.q.GetUserByID(ctx, userID); err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	roles, err := s.q.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{}, roles...), nil
+}
+
+// Assign gives userID role on behalf of actorID. Assigning a role the user
+// already holds is a no-op.
+func (s *RoleService) Assign(ctx context.Context, actorID, userID int64, role string) error {
+	return s.change(ctx, actorID, userID, role, func(qtx *sqlc.Queries) (int64, error) {
+		return qtx.AddUserRole(ctx, sqlc.AddUserRoleParams{UserID: userID, Role: role})
+	})
+}
+
+// Remove takes role away from userID on behalf of actorID.
+func (s *RoleService) Remove(ctx context.Context, actorID, userID int64, role string) error {
+	n := int64(0)
+	err := s.change(ctx, actorID, userID, role, func(qtx *sqlc.Queries) (int64, error) {
+		var err error
+		n, err = qtx.RemoveUserRole(ctx, sqlc.RemoveUserRoleParams{UserID: userID, Role: role})
+		return n, err
+	})
+	if err == nil && n == 0 {
+		return ErrRoleNotHeld
+	}
+	return err
+}
+
+// change applies an update to userID's roles and, if it changed anything,
+// revokes their access tokens. Nobody edits their own roles, so an admin
+// can't lock themselves out.
+func (s *RoleService) change(ctx context.Context, actorID, userID int64, role string, update func(*sqlc.Queries) (int64, error)) error {
+	if role == "" {
+		return ErrRoleRequired
+	}
+	if actorID == userID {
+		return ErrOwnRoles
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+
+	if _, err := qtx.GetUserByID(ctx, userID); err == sql.ErrNoRows {
+		return ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	found, err := qtx.RoleExists(ctx, role)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrRoleNotFound
+	}
+
+	n, err := update(qtx)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return tx.Commit()
+	}
+	if _, err := qtx.BumpTokenVersion(ctx, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.revocations.forget(userID)
+	return nil
+}
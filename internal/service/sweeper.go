@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// SweepFunc deletes expired rows and reports how many it removed.
+type SweepFunc func(ctx context.Context) (int64, error)
+
+// Sweeper periodically runs a cleanup job such as releasing expired stock
+// reservations. Reads already ignore expired rows, so sweeping only keeps
+// tables from growing; a missed run never changes behaviour.
+type Sweeper struct {
+	name     string
+	interval time.Duration
+	sweep    SweepFunc
+}
+
+func NewSweeper(name string, interval time.Duration, sweep SweepFunc) *Sweeper {
+	return &Sweeper{name: name, interval: interval, sweep: sweep}
+}
+
+// Run sweeps every interval until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) {
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			n, err := s.sweep(ctx)
+			if err != nil {
+				log.Printf("%s sweep error: %v", s.name, err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("%s sweep: removed %d expired rows", s.name, n)
+			}
+		}
+	}
+}
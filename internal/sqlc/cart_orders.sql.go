@@ -19,21 +19,65 @@ func (q *Queries) ClearCartItems(ctx context.Context, cartID int64) error {
 }
 
 const createOrder = `-- name: CreateOrder :one
-INSERT INTO orders (user_id, status, total_cents)
-VALUES ($1, 'placed', $2)
-RETURNING id
+INSERT INTO orders (user_id, status, total_cents, currency)
+VALUES ($1, 'placed', $2, $3)
+RETURNING id, user_id, status, total_cents, created_at, currency
 `
 
 type CreateOrderParams struct {
-	UserID     int64 `json:"user_id"`
-	TotalCents int32 `json:"total_cents"`
+	UserID     int64  `json:"user_id"`
+	TotalCents int64  `json:"total_cents"`
+	Currency   string `json:"currency"`
 }
 
-func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, createOrder, arg.UserID, arg.TotalCents)
-	var id int64
-	err := row.Scan(&id)
-	return id, err
+func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, createOrder, arg.UserID, arg.TotalCents, arg.Currency)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.TotalCents,
+		&i.CreatedAt,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const createOrderAddress = `-- name: CreateOrderAddress :exec
+INSERT INTO order_addresses (order_id, kind, full_name, company, line1, line2, city, region, postal_code, country, phone)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+`
+
+type CreateOrderAddressParams struct {
+	OrderID    int64  `json:"order_id"`
+	Kind       string `json:"kind"`
+	FullName   string `json:"full_name"`
+	Company    string `json:"company"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone"`
+}
+
+func (q *Queries) CreateOrderAddress(ctx context.Context, arg CreateOrderAddressParams) error {
+	_, err := q.db.ExecContext(ctx, createOrderAddress,
+		arg.OrderID,
+		arg.Kind,
+		arg.FullName,
+		arg.Company,
+		arg.Line1,
+		arg.Line2,
+		arg.City,
+		arg.Region,
+		arg.PostalCode,
+		arg.Country,
+		arg.Phone,
+	)
+	return err
 }
 
 const createOrderItem = `-- name: CreateOrderItem :exec
@@ -44,9 +88,9 @@ VALUES ($1, $2, $3, $4, $5)
 type CreateOrderItemParams struct {
 	OrderID        int64 `json:"order_id"`
 	ProductID      int64 `json:"product_id"`
-	UnitPriceCents int32 `json:"unit_price_cents"`
+	UnitPriceCents int64 `json:"unit_price_cents"`
 	Qty            int32 `json:"qty"`
-	LineTotalCents int32 `json:"line_total_cents"`
+	LineTotalCents int64 `json:"line_total_cents"`
 }
 
 func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) error {
@@ -60,7 +104,7 @@ func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams
 	return err
 }
 
-const decrementProductStock = `-- name: DecrementProductStock :exec
+const decrementProductStock = `-- name: DecrementProductStock :execrows
 UPDATE products
 SET stock = stock - $2, updated_at = now()
 WHERE id = $1 AND stock >= $2
@@ -71,9 +115,12 @@ type DecrementProductStockParams struct {
 	Stock int32 `json:"stock"`
 }
 
-func (q *Queries) DecrementProductStock(ctx context.Context, arg DecrementProductStockParams) error {
-	_, err := q.db.ExecContext(ctx, decrementProductStock, arg.ID, arg.Stock)
-	return err
+func (q *Queries) DecrementProductStock(ctx context.Context, arg DecrementProductStockParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, decrementProductStock, arg.ID, arg.Stock)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const deleteCartItem = `-- name: DeleteCartItem :exec
@@ -85,7 +132,7 @@ func (q *Queries) DeleteCartItem(ctx context.Context, id int64) error {
 	return err
 }
 
-const deleteCartItemInCart = `-- name: DeleteCartItemInCart :exec
+const deleteCartItemInCart = `-- name: DeleteCartItemInCart :execrows
 DELETE FROM cart_items
 WHERE id = $1 AND cart_id = $2
 `
@@ -95,15 +142,18 @@ type DeleteCartItemInCartParams struct {
 	CartID int64 `json:"cart_id"`
 }
 
-func (q *Queries) DeleteCartItemInCart(ctx context.Context, arg DeleteCartItemInCartParams) error {
-	_, err := q.db.ExecContext(ctx, deleteCartItemInCart, arg.ID, arg.CartID)
-	return err
+func (q *Queries) DeleteCartItemInCart(ctx context.Context, arg DeleteCartItemInCartParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCartItemInCart, arg.ID, arg.CartID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getActiveCartID = `-- name: GetActiveCartID :one
 SELECT id
 FROM carts
-WHERE user_id = $1 AND status = 'active'
+WHERE user_id = $1::bigint AND status = 'active'
 LIMIT 1
 `
 
@@ -114,14 +164,60 @@ func (q *Queries) GetActiveCartID(ctx context.Context, userID int64) (int64, err
 	return id, err
 }
 
+const getCartCurrency = `-- name: GetCartCurrency :one
+SELECT p.currency
+FROM cart_items ci
+JOIN products p ON p.id = ci.product_id
+WHERE ci.cart_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetCartCurrency(ctx context.Context, cartID int64) (string, error) {
+	row := q.db.QueryRowContext(ctx, getCartCurrency, cartID)
+	var currency string
+	err := row.Scan(&currency)
+	return currency, err
+}
+
+const getCartItem = `-- name: GetCartItem :one
+SELECT id, cart_id, product_id, qty
+FROM cart_items
+WHERE id = $1 AND cart_id = $2
+`
+
+type GetCartItemParams struct {
+	ID     int64 `json:"id"`
+	CartID int64 `json:"cart_id"`
+}
+
+type GetCartItemRow struct {
+	ID        int64 `json:"id"`
+	CartID    int64 `json:"cart_id"`
+	ProductID int64 `json:"product_id"`
+	Qty       int32 `json:"qty"`
+}
+
+func (q *Queries) GetCartItem(ctx context.Context, arg GetCartItemParams) (GetCartItemRow, error) {
+	row := q.db.QueryRowContext(ctx, getCartItem, arg.ID, arg.CartID)
+	var i GetCartItemRow
+	err := row.Scan(
+		&i.ID,
+		&i.CartID,
+		&i.ProductID,
+		&i.Qty,
+	)
+	return i, err
+}
+
 const getOrCreateActiveCart = `-- name: GetOrCreateActiveCart :one
 WITH existing AS (
-  SELECT c.id FROM carts c WHERE c.user_id = $1 AND c.status = 'active' LIMIT 1
+  SELECT c.id FROM carts c WHERE c.user_id = $1::bigint AND c.status = 'active' LIMIT 1
 ),
 inserted AS (
   INSERT INTO carts (user_id, status)
-  SELECT $1, 'active'
+  SELECT $1::bigint, 'active'
   WHERE NOT EXISTS (SELECT 1 FROM existing)
+  ON CONFLICT (user_id) WHERE status = 'active' DO NOTHING
   RETURNING id
 )
 SELECT id FROM inserted
@@ -137,14 +233,35 @@ func (q *Queries) GetOrCreateActiveCart(ctx context.Context, userID int64) (int6
 	return id, err
 }
 
+const incrementProductStock = `-- name: IncrementProductStock :exec
+UPDATE products
+SET stock = stock + $2, updated_at = now()
+WHERE id = $1
+`
+
+type IncrementProductStockParams struct {
+	ID    int64 `json:"id"`
+	Stock int32 `json:"stock"`
+}
+
+func (q *Queries) IncrementProductStock(ctx context.Context, arg IncrementProductStockParams) error {
+	_, err := q.db.ExecContext(ctx, incrementProductStock, arg.ID, arg.Stock)
+	return err
+}
+
 const listCartItems = `-- name: ListCartItems :many
 SELECT
   ci.id,
   ci.product_id,
   ci.qty,
   p.name,
-  (p.price_cents::int) AS price_cents,
-  (p.price_cents::int * ci.qty)::int AS line_total_cents
+  p.price_cents,
+  p.currency,
+  (p.price_cents * ci.qty)::bigint AS line_total_cents,
+  (p.stock - COALESCE((
+    SELECT SUM(r.qty) FROM stock_reservations r
+    WHERE r.product_id = ci.product_id AND r.cart_id <> ci.cart_id AND r.expires_at > now()
+  ), 0))::int AS available
 FROM cart_items ci
 JOIN products p ON p.id = ci.product_id
 WHERE ci.cart_id = $1
@@ -156,8 +273,10 @@ type ListCartItemsRow struct {
 	ProductID      int64  `json:"product_id"`
 	Qty            int32  `json:"qty"`
 	Name           string `json:"name"`
-	PriceCents     int32  `json:"price_cents"`
-	LineTotalCents int32  `json:"line_total_cents"`
+	PriceCents     int64  `json:"price_cents"`
+	Currency       string `json:"currency"`
+	LineTotalCents int64  `json:"line_total_cents"`
+	Available      int32  `json:"available"`
 }
 
 func (q *Queries) ListCartItems(ctx context.Context, cartID int64) ([]ListCartItemsRow, error) {
@@ -175,7 +294,9 @@ func (q *Queries) ListCartItems(ctx context.Context, cartID int64) ([]ListCartIt
 			&i.Qty,
 			&i.Name,
 			&i.PriceCents,
+			&i.Currency,
 			&i.LineTotalCents,
+			&i.Available,
 		); err != nil {
 			return nil, err
 		}
@@ -191,19 +312,34 @@ func (q *Queries) ListCartItems(ctx context.Context, cartID int64) ([]ListCartIt
 }
 
 const lockCartItemsForCheckout = `-- name: LockCartItemsForCheckout :many
-SELECT ci.product_id, ci.qty, p.price_cents, p.stock, p.is_active
+SELECT
+  ci.product_id,
+  ci.qty,
+  p.name,
+  p.price_cents,
+  p.currency,
+  p.stock,
+  p.is_active,
+  COALESCE((
+    SELECT SUM(r.qty) FROM stock_reservations r
+    WHERE r.product_id = p.id AND r.cart_id <> ci.cart_id AND r.expires_at > now()
+  ), 0)::int AS reserved_elsewhere
 FROM cart_items ci
 JOIN products p ON p.id = ci.product_id
 WHERE ci.cart_id = $1
+ORDER BY p.id
 FOR UPDATE
 `
 
 type LockCartItemsForCheckoutRow struct {
-	ProductID  int64   `json:"product_id"`
-	Qty        int32   `json:"qty"`
-	PriceCents float64 `json:"price_cents"`
-	Stock      int32   `json:"stock"`
-	IsActive   bool    `json:"is_active"`
+	ProductID         int64  `json:"product_id"`
+	Qty               int32  `json:"qty"`
+	Name              string `json:"name"`
+	PriceCents        int64  `json:"price_cents"`
+	Currency          string `json:"currency"`
+	Stock             int32  `json:"stock"`
+	IsActive          bool   `json:"is_active"`
+	ReservedElsewhere int32  `json:"reserved_elsewhere"`
 }
 
 func (q *Queries) LockCartItemsForCheckout(ctx context.Context, cartID int64) ([]LockCartItemsForCheckoutRow, error) {
@@ -218,9 +354,12 @@ func (q *Queries) LockCartItemsForCheckout(ctx context.Context, cartID int64) ([
 		if err := rows.Scan(
 			&i.ProductID,
 			&i.Qty,
+			&i.Name,
 			&i.PriceCents,
+			&i.Currency,
 			&i.Stock,
 			&i.IsActive,
+			&i.ReservedElsewhere,
 		); err != nil {
 			return nil, err
 		}
@@ -262,7 +401,7 @@ func (q *Queries) UpdateCartItemQty(ctx context.Context, arg UpdateCartItemQtyPa
 	return err
 }
 
-const updateCartItemQtyInCart = `-- name: UpdateCartItemQtyInCart :exec
+const updateCartItemQtyInCart = `-- name: UpdateCartItemQtyInCart :execrows
 UPDATE cart_items
 SET qty = $3, updated_at = now()
 WHERE id = $1 AND cart_id = $2
@@ -274,9 +413,12 @@ type UpdateCartItemQtyInCartParams struct {
 	Qty    int32 `json:"qty"`
 }
 
-func (q *Queries) UpdateCartItemQtyInCart(ctx context.Context, arg UpdateCartItemQtyInCartParams) error {
-	_, err := q.db.ExecContext(ctx, updateCartItemQtyInCart, arg.ID, arg.CartID, arg.Qty)
-	return err
+func (q *Queries) UpdateCartItemQtyInCart(ctx context.Context, arg UpdateCartItemQtyInCartParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateCartItemQtyInCart, arg.ID, arg.CartID, arg.Qty)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const upsertCartItem = `-- name: UpsertCartItem :one
@@ -5,15 +5,35 @@
 package sqlc
 
 import (
+	"database/sql"
 	"time"
 )
 
+type Address struct {
+	ID                int64     `json:"id"`
+	UserID            int64     `json:"user_id"`
+	FullName          string    `json:"full_name"`
+	Company           string    `json:"company"`
+	Line1             string    `json:"line1"`
+	Line2             string    `json:"line2"`
+	City              string    `json:"city"`
+	Region            string    `json:"region"`
+	PostalCode        string    `json:"postal_code"`
+	Country           string    `json:"country"`
+	Phone             string    `json:"phone"`
+	IsDefaultShipping bool      `json:"is_default_shipping"`
+	IsDefaultBilling  bool      `json:"is_default_billing"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
 type Cart struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             int64          `json:"id"`
+	UserID         sql.NullInt64  `json:"user_id"`
+	Status         string         `json:"status"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	GuestTokenHash sql.NullString `json:"guest_token_hash"`
 }
 
 type CartItem struct {
@@ -25,11 +45,86 @@ type CartItem struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type EmailChangeToken struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	NewEmail  string       `json:"new_email"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type EmailVerificationToken struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	ID              int64         `json:"id"`
+	Scope           string        `json:"scope"`
+	Key             string        `json:"key"`
+	RequestHash     string        `json:"request_hash"`
+	StatusCode      sql.NullInt32 `json:"status_code"`
+	ContentType     string        `json:"content_type"`
+	ResponseBody    []byte        `json:"response_body"`
+	CreatedAt       time.Time     `json:"created_at"`
+	CompletedAt     sql.NullTime  `json:"completed_at"`
+	ResponseHeaders string        `json:"response_headers"`
+}
+
+type LoginAttempt struct {
+	Key           string       `json:"key"`
+	Failures      int32        `json:"failures"`
+	LastFailureAt time.Time    `json:"last_failure_at"`
+	LockedUntil   sql.NullTime `json:"locked_until"`
+}
+
+type MfaChallenge struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	Attempts  int32        `json:"attempts"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type OidcLoginState struct {
+	ID           int64     `json:"id"`
+	StateHash    string    `json:"state_hash"`
+	Provider     string    `json:"provider"`
+	CodeVerifier string    `json:"code_verifier"`
+	Nonce        string    `json:"nonce"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 type Order struct {
 	ID         int64     `json:"id"`
 	UserID     int64     `json:"user_id"`
 	Status     string    `json:"status"`
-	TotalCents int32     `json:"total_cents"`
+	TotalCents int64     `json:"total_cents"`
+	CreatedAt  time.Time `json:"created_at"`
+	Currency   string    `json:"currency"`
+}
+
+type OrderAddress struct {
+	OrderID    int64     `json:"order_id"`
+	Kind       string    `json:"kind"`
+	FullName   string    `json:"full_name"`
+	Company    string    `json:"company"`
+	Line1      string    `json:"line1"`
+	Line2      string    `json:"line2"`
+	City       string    `json:"city"`
+	Region     string    `json:"region"`
+	PostalCode string    `json:"postal_code"`
+	Country    string    `json:"country"`
+	Phone      string    `json:"phone"`
 	CreatedAt  time.Time `json:"created_at"`
 }
 
@@ -37,27 +132,127 @@ type OrderItem struct {
 	ID             int64 `json:"id"`
 	OrderID        int64 `json:"order_id"`
 	ProductID      int64 `json:"product_id"`
-	UnitPriceCents int32 `json:"unit_price_cents"`
+	UnitPriceCents int64 `json:"unit_price_cents"`
 	Qty            int32 `json:"qty"`
-	LineTotalCents int32 `json:"line_total_cents"`
+	LineTotalCents int64 `json:"line_total_cents"`
+}
+
+type OrderStatusHistory struct {
+	ID         int64          `json:"id"`
+	OrderID    int64          `json:"order_id"`
+	FromStatus sql.NullString `json:"from_status"`
+	ToStatus   string         `json:"to_status"`
+	ChangedBy  sql.NullInt64  `json:"changed_by"`
+	Reason     string         `json:"reason"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
 }
 
 type Product struct {
 	ID          int64     `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
-	PriceCents  float64   `json:"price_cents"`
+	PriceCents  int64     `json:"price_cents"`
 	Stock       int32     `json:"stock"`
 	IsActive    bool      `json:"is_active"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	Currency    string    `json:"currency"`
+}
+
+type RecoveryCode struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type RefreshToken struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	FamilyID  string       `json:"family_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type RevokedToken struct {
+	Jti       string    `json:"jti"`
+	UserID    int64     `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type Role struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type RolePermission struct {
+	Role       string `json:"role"`
+	Permission string `json:"permission"`
+}
+
+type StockReservation struct {
+	ID        int64     `json:"id"`
+	CartID    int64     `json:"cart_id"`
+	ProductID int64     `json:"product_id"`
+	Qty       int32     `json:"qty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type User struct {
-	ID           int64     `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"password_hash"`
-	Role         string    `json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                  int64        `json:"id"`
+	Email               string       `json:"email"`
+	PasswordHash        string       `json:"password_hash"`
+	CreatedAt           time.Time    `json:"created_at"`
+	UpdatedAt           time.Time    `json:"updated_at"`
+	TokenVersion        int32        `json:"token_version"`
+	EmailVerifiedAt     sql.NullTime `json:"email_verified_at"`
+	DeletionRequestedAt sql.NullTime `json:"deletion_requested_at"`
+	DeletedAt           sql.NullTime `json:"deleted_at"`
+	FirstName           string       `json:"first_name"`
+	LastName            string       `json:"last_name"`
+	Phone               string       `json:"phone"`
+}
+
+type UserIdentity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserRole struct {
+	UserID    int64     `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserTotp struct {
+	UserID       int64        `json:"user_id"`
+	Secret       string       `json:"secret"`
+	ConfirmedAt  sql.NullTime `json:"confirmed_at"`
+	LastUsedStep int64        `json:"last_used_step"`
+	CreatedAt    time.Time    `json:"created_at"`
 }